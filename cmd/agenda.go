@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"punchlist/config"
+	"punchlist/filter"
+	"punchlist/task"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// agenda sections in display order
+var agendaGroups = []string{"Overdue", "Today", "Tomorrow", "This week", "Later"}
+
+// widest title before agenda lines truncate it
+const agendaTitleWidth = 50
+
+// create the agenda command
+func newAgendaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "agenda [@project,...] [filter]",
+		Short: "Show overdue, today's and upcoming tasks",
+		Long: `Show open tasks with due dates, grouped into Overdue, Today, Tomorrow,
+This week (the next 7 days) and Later. Closed tasks such as DONE and NOTDO are
+left out, as are tasks without a due date.
+
+An optional filter expression narrows the tasks, as in pin ls:
+  pin agenda tag:launch
+
+Show several registered projects at once, each task prefixed with its alias:
+  pin agenda --all
+  pin agenda @work,@home
+
+Exits with status 1 when anything is overdue and 2 on errors, so it can drive
+shell prompts and cron jobs.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			days, _ := cmd.Flags().GetInt("days")
+			all, _ := cmd.Flags().GetBool("all")
+			overdue, err := showAgenda(args, days, all, time.Now())
+			if err != nil {
+				if !printNotPunchlistError(err) {
+					fmt.Printf("Error showing agenda: %v\n", err)
+				}
+				return exitError{code: 2}
+			}
+			if overdue > 0 {
+				return exitError{code: 1}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Int("days", 0, "Only show tasks due within this many days; overdue tasks always show (0 for all)")
+	cmd.Flags().Bool("all", false, "Show tasks from every registered project")
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) == 0 && strings.HasPrefix(toComplete, viewPrefix) {
+			return projectCompletions(toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return cmd
+}
+
+// print the agenda for the active project, or for registered projects with
+// --all or @a,@b, and return how many tasks are overdue
+func showAgenda(filterArgs []string, days int, all bool, now time.Time) (int, error) {
+	projects, filterArgs, err := projectsFromArgs(filterArgs, all)
+	if err != nil {
+		return 0, err
+	}
+	expr := strings.TrimSpace(strings.Join(filterArgs, " "))
+
+	var tasks []*task.Task
+	var projectOf map[*task.Task]string
+	if projects == nil {
+		if tasks, err = agendaTasks(expr, days, now); err != nil {
+			return 0, err
+		}
+	} else {
+		if err := checkFilterSyntax(expr); err != nil {
+			return 0, err
+		}
+		projectOf = map[*task.Task]string{}
+		err := forEachProject(projects, func(p config.Project) error {
+			projectTasks, err := agendaTasks(expr, days, now)
+			if err != nil {
+				return err
+			}
+			for _, t := range projectTasks {
+				projectOf[t] = p.Alias
+			}
+			tasks = append(tasks, projectTasks...)
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if len(tasks) == 0 {
+		if days > 0 {
+			fmt.Printf("Nothing due in the next %d days.\n", days)
+		} else {
+			fmt.Println("Nothing due.")
+		}
+		return 0, nil
+	}
+
+	keys, _ := parseSortKeys("due,pri,id")
+	sortTasks(tasks, keys, false)
+	printAgenda(tasks, now, projectOf)
+
+	overdue := 0
+	for _, t := range tasks {
+		if daysUntil(*t.Due, now) < 0 {
+			overdue++
+		}
+	}
+	return overdue, nil
+}
+
+// open tasks in the active project with a due date, matching a filter
+func agendaTasks(expr string, days int, now time.Time) ([]*task.Task, error) {
+	taskFilter, err := filter.ParseAt(expr, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	tasksPath, err := tasksDir()
+	if err != nil {
+		return nil, err
+	}
+	workflow := task.ActiveWorkflow()
+	return selectTasks(tasksPath, func(t *task.Task) bool {
+		if t.Due == nil || workflow.IsClosed(t.State) || !taskFilter.Match(t) {
+			return false
+		}
+		return days <= 0 || daysUntil(*t.Due, now) <= days
+	})
+}
+
+// pick the agenda section for a due date
+func agendaGroup(days int) int {
+	switch {
+	case days < 0:
+		return 0
+	case days == 0:
+		return 1
+	case days == 1:
+		return 2
+	case days <= 7:
+		return 3
+	}
+	return 4
+}
+
+// print tasks under their section headings with aligned columns
+func printAgenda(tasks []*task.Task, now time.Time, projectOf map[*task.Task]string) {
+	idWidth := maxIDWidth(tasks)
+	if configWidth := loadIDWidth(); configWidth > idWidth {
+		idWidth = configWidth
+	}
+	stateWidth, titleWidth, projectWidth := 0, 0, 0
+	for _, t := range tasks {
+		if n := utf8.RuneCountInString(projectOf[t]); n > projectWidth {
+			projectWidth = n
+		}
+		if n := utf8.RuneCountInString(string(t.State)); n > stateWidth {
+			stateWidth = n
+		}
+		if n := utf8.RuneCountInString(t.Title); n > titleWidth {
+			titleWidth = n
+		}
+	}
+	if titleWidth > agendaTitleWidth {
+		titleWidth = agendaTitleWidth
+	}
+
+	grouped := make([][]*task.Task, len(agendaGroups))
+	for _, t := range tasks {
+		group := agendaGroup(daysUntil(*t.Due, now))
+		grouped[group] = append(grouped[group], t)
+	}
+	color := colorEnabled()
+	first := true
+	for i, group := range grouped {
+		if len(group) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Println()
+		}
+		first = false
+		fmt.Println(paint(fmt.Sprintf("%s (%d)", agendaGroups[i], len(group)), color, "bold"))
+		for _, t := range group {
+			fmt.Print("  ")
+			if projectOf != nil {
+				fmt.Printf("%-*s ", projectWidth, projectOf[t])
+			}
+			fmt.Printf("%*d %s%s %s  %s\n",
+				idWidth,
+				t.ID,
+				paint(string(t.State), color, stateStyles(t.State)...),
+				strings.Repeat(" ", stateWidth-utf8.RuneCountInString(string(t.State))),
+				padText(truncateText(titleWidth, t.Title), titleWidth, false),
+				paint(dueRelative(*t.Due, now), color, dueStyles(daysUntil(*t.Due, now), false)...),
+			)
+		}
+	}
+}
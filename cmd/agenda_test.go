@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test relative due descriptions
+func TestDueRelative(t *testing.T) {
+	now := time.Date(2026, 5, 10, 9, 0, 0, 0, time.Local)
+	cases := map[int]string{-3: "3d overdue", -1: "1d overdue", 0: "today", 1: "tomorrow", 5: "in 5d"}
+	for days, expected := range cases {
+		due := time.Date(2026, 5, 10+days, 12, 0, 0, 0, time.Local)
+		if got := dueRelative(due, now); got != expected {
+			t.Errorf("dueRelative(%+d days) = %q, want %q", days, got, expected)
+		}
+	}
+	if agendaGroup(7) != 3 || agendaGroup(8) != 4 {
+		t.Errorf("Expected the next 7 days to be this week")
+	}
+}
+
+// test agenda grouping, closed tasks, --days and the exit status
+func TestAgendaCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	now := time.Now()
+	day := func(offset int) string {
+		return "by:" + now.AddDate(0, 0, offset).Format("2006-01-02")
+	}
+	executeCommand("init")
+	executeCommand("todo", "Late report", day(-3))
+	executeCommand("todo", "Standup notes", day(0))
+	executeCommand("todo", "Vendor call", day(1))
+	executeCommand("todo", "Plan offsite", day(30))
+	executeCommand("todo", "Finished already", day(-5))
+	executeCommand("todo", "No date")
+	executeCommand("done", "5")
+
+	output, err := executeCommand("agenda")
+	var exitErr exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 1 {
+		t.Errorf("Expected exit status 1 with overdue tasks, got %v", err)
+	}
+	for _, expected := range []string{"Overdue (1)", "Late report", "3d overdue", "Today (1)", "Tomorrow (1)", "Later (1)", "in 30d"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in agenda:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "Finished already") || strings.Contains(output, "No date") {
+		t.Errorf("Expected closed and undated tasks to be skipped:\n%s", output)
+	}
+	if strings.Index(output, "Overdue") > strings.Index(output, "Today") {
+		t.Errorf("Expected Overdue before Today:\n%s", output)
+	}
+
+	output, _ = executeCommand("agenda", "--days", "1")
+	if strings.Contains(output, "Plan offsite") || !strings.Contains(output, "Late report") {
+		t.Errorf("Expected --days to drop later tasks but keep overdue ones:\n%s", output)
+	}
+
+	executeCommand("done", "1")
+	output, err = executeCommand("agenda")
+	if err != nil {
+		t.Errorf("Expected success with nothing overdue, got %v", err)
+	}
+	if strings.Contains(output, "Overdue") {
+		t.Errorf("Expected no overdue section:\n%s", output)
+	}
+}
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"fmt"
+	"punchlist/filter"
+	"punchlist/task"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// narrowest a board column gets before columns are dropped
+const minBoardColumnWidth = 16
+
+// width to lay out a board for when neither the terminal nor COLUMNS gives one
+const defaultBoardWidth = 120
+
+// line between board columns
+const boardGap = " │ "
+
+// one state's column of cards
+type boardColumn struct {
+	state task.State
+	tasks []*task.Task
+}
+
+// how a board is drawn
+type boardLayout struct {
+	width int
+	// rows of cards to draw, or 0 for as many as the longest column
+	height int
+	color  bool
+	// first column to draw, so a selected column can scroll into view
+	first int
+	// the highlighted card, or -1 for none
+	selectedColumn int
+	selectedRow    int
+	now            time.Time
+}
+
+// create the board command
+func newBoardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "board [path] [filter]",
+		Short: "Show tasks as a kanban board with a column per state",
+		Long: `Show tasks as side-by-side columns, one per state in ls_state_order order,
+with each column's count in its heading. Cards show the id, a priority badge
+and the title, truncated to fit the terminal. When not every column fits, empty
+ones are left out first, and those not drawn are listed below the board.
+
+An optional filter expression narrows the cards, as in pin ls:
+  pin board tag:launch
+  pin board open --hide-empty
+
+Use pin tui --board, or v in pin tui, to move cards between columns.`,
+		ValidArgsFunction: lsArgCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			targetPath, filterArgs := extractTargetPath(args)
+			run := func() error { return showBoard(cmd, filterArgs) }
+
+			var err error
+			if targetPath != "" {
+				root, rootErr := punchlistRootFromPath(targetPath)
+				if rootErr != nil {
+					err = rootErr
+				} else {
+					err = withRoot(root, run)
+				}
+			} else {
+				err = run()
+			}
+			if err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error showing board: %v\n", err)
+			}
+		},
+	}
+	cmd.Flags().String("sort", "", "Sort cards within columns, e.g. pri,due (- for descending)")
+	cmd.Flags().Bool("hide-empty", false, "Leave out columns without cards")
+	return cmd
+}
+
+// print the board for the active project
+func showBoard(cmd *cobra.Command, filterArgs []string) error {
+	taskFilter, err := filter.Parse(strings.TrimSpace(strings.Join(filterArgs, " ")))
+	if err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	sortKeys, err := sortKeysFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	tasksPath, err := tasksDir()
+	if err != nil {
+		return err
+	}
+	tasks, err := selectTasks(tasksPath, taskFilter.Match)
+	if err != nil {
+		return err
+	}
+	sortTasks(tasks, sortKeys, false)
+
+	hideEmpty, _ := cmd.Flags().GetBool("hide-empty")
+	columns := buildBoard(tasks, hideEmpty)
+	if len(columns) == 0 {
+		fmt.Println("No tasks found.")
+		return nil
+	}
+
+	width := terminalWidth()
+	if width <= 0 {
+		width = defaultBoardWidth
+	}
+	visible, hidden := fitBoardColumns(columns, width)
+	layout := boardLayout{width: width, color: colorEnabled(), selectedColumn: -1}
+	for _, line := range renderBoard(visible, layout) {
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	if len(hidden) > 0 {
+		names := []string{}
+		for _, column := range hidden {
+			names = append(names, fmt.Sprintf("%s (%d)", column.state, len(column.tasks)))
+		}
+		fmt.Printf("\nNot shown: %s\n", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// split columns into those drawn in width and those left out; when they
+// don't all fit, empty columns give way first so cards stay on screen
+func fitBoardColumns(columns []boardColumn, width int) ([]boardColumn, []boardColumn) {
+	shown := boardColumnsShown(len(columns), width)
+	if shown == len(columns) {
+		return columns, nil
+	}
+	filled, empty := []boardColumn{}, []boardColumn{}
+	for _, column := range columns {
+		if len(column.tasks) > 0 {
+			filled = append(filled, column)
+		} else {
+			empty = append(empty, column)
+		}
+	}
+	if len(filled) == 0 {
+		return columns[:shown], columns[shown:]
+	}
+	if len(filled) > shown {
+		return filled[:shown], append(filled[shown:], empty...)
+	}
+	return filled, empty
+}
+
+// group tasks into a column per state, in ls_state_order order with custom
+// states after; cards keep the order of tasks
+func buildBoard(tasks []*task.Task, hideEmpty bool) []boardColumn {
+	states := task.ActiveWorkflow().Names()
+	byState := map[task.State][]*task.Task{}
+	for _, t := range tasks {
+		if _, seen := byState[t.State]; !seen {
+			if _, known := task.ActiveWorkflow().Def(t.State); !known {
+				states = append(states, t.State)
+			}
+		}
+		byState[t.State] = append(byState[t.State], t)
+	}
+	index := buildStateOrderIndex(loadStateOrder())
+	sort.SliceStable(states, func(i, j int) bool {
+		return stateRank(index, states[i]) < stateRank(index, states[j])
+	})
+
+	columns := []boardColumn{}
+	for _, state := range states {
+		if hideEmpty && len(byState[state]) == 0 {
+			continue
+		}
+		columns = append(columns, boardColumn{state: state, tasks: byState[state]})
+	}
+	return columns
+}
+
+// how many columns fit in width at the minimum column width
+func boardColumnsShown(count, width int) int {
+	gapWidth := utf8.RuneCountInString(boardGap)
+	fit := (width + gapWidth) / (minBoardColumnWidth + gapWidth)
+	if fit < 1 {
+		fit = 1
+	}
+	if fit > count {
+		fit = count
+	}
+	return fit
+}
+
+// draw the board as lines exactly layout.width wide: a heading with the
+// count per column, a rule, then a card per line
+func renderBoard(columns []boardColumn, layout boardLayout) []string {
+	if layout.now.IsZero() {
+		layout.now = time.Now()
+	}
+	gapWidth := utf8.RuneCountInString(boardGap)
+	shown := boardColumnsShown(len(columns), layout.width)
+	first := layout.first
+	if first > len(columns)-shown {
+		first = len(columns) - shown
+	}
+	if first < 0 {
+		first = 0
+	}
+	visible := columns[first : first+shown]
+	columnWidth := (layout.width - gapWidth*(shown-1)) / shown
+	if columnWidth < 1 {
+		columnWidth = 1
+	}
+
+	rows := layout.height
+	if rows <= 0 {
+		for _, column := range visible {
+			if len(column.tasks) > rows {
+				rows = len(column.tasks)
+			}
+		}
+	}
+	idWidth := 1
+	for _, column := range visible {
+		for _, t := range column.tasks {
+			if n := len(strconv.Itoa(t.ID)); n > idWidth {
+				idWidth = n
+			}
+		}
+	}
+
+	// scroll each column so its selected card shows
+	offsets := make([]int, len(visible))
+	for i := range visible {
+		if first+i == layout.selectedColumn && layout.selectedRow >= rows {
+			offsets[i] = layout.selectedRow - rows + 1
+		}
+	}
+
+	gap := paint(boardGap, layout.color, "dim")
+	joinCells := func(cells []string) string {
+		line := strings.Join(cells, gap)
+		used := shown*columnWidth + gapWidth*(shown-1)
+		return line + strings.Repeat(" ", max(0, layout.width-used))
+	}
+
+	lines := []string{}
+	cells := make([]string, len(visible))
+	for i, column := range visible {
+		heading := truncateText(columnWidth, fmt.Sprintf("%s (%d)", column.state, len(column.tasks)))
+		styles := append([]string{"bold"}, stateStyles(column.state)...)
+		cells[i] = paint(padText(heading, columnWidth, false), layout.color, styles...)
+	}
+	lines = append(lines, joinCells(cells))
+	for i := range visible {
+		cells[i] = paint(strings.Repeat("─", columnWidth), layout.color, "dim")
+	}
+	lines = append(lines, joinCells(cells))
+
+	for row := 0; row < rows; row++ {
+		cells := make([]string, len(visible))
+		for i, column := range visible {
+			index := offsets[i] + row
+			if index >= len(column.tasks) {
+				cells[i] = strings.Repeat(" ", columnWidth)
+				continue
+			}
+			selected := first+i == layout.selectedColumn && index == layout.selectedRow
+			cells[i] = boardCard(column.tasks[index], columnWidth, idWidth, selected, layout)
+		}
+		lines = append(lines, joinCells(cells))
+	}
+	return lines
+}
+
+// one card: id, priority badge and title, padded to width
+func boardCard(t *task.Task, width, idWidth int, selected bool, layout boardLayout) string {
+	id := padText(strconv.Itoa(t.ID), idWidth, true)
+	badge := ""
+	if t.Priority > 0 {
+		badge = "P" + strconv.Itoa(t.Priority)
+	}
+	plain := id + " "
+	if badge != "" {
+		plain += badge + " "
+	}
+	titleWidth := width - utf8.RuneCountInString(plain)
+	if titleWidth < 1 {
+		return paint(padText(truncateText(width, plain+t.Title), width, false), layout.color && selected, "reverse")
+	}
+	title := padText(truncateText(titleWidth, t.Title), titleWidth, false)
+	if selected {
+		return paint(plain+title, layout.color, "reverse")
+	}
+	if !layout.color {
+		return plain + title
+	}
+	card := paint(id, true, "dim") + " "
+	if badge != "" {
+		card += paint(badge, true, cellStyles(t, "priority", layout.now)...) + " "
+	}
+	return card + paint(title, true, cellStyles(t, "title", layout.now)...)
+}
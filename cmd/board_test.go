@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// test board columns, counts, badges and dropped columns
+func TestBoardCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	t.Setenv("COLUMNS", "200")
+
+	executeCommand("init")
+	executeCommand("todo", "Write release notes", "pri:1")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("todo", "Ship it")
+	executeCommand("start", "2")
+	executeCommand("done", "3")
+
+	output, _ := executeCommand("board")
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a heading, a rule and one row of cards:\n%s", output)
+	}
+	headings := strings.Split(lines[0], "│")
+	if len(headings) != 6 || strings.TrimSpace(headings[0]) != "BEGUN (1)" || strings.TrimSpace(headings[2]) != "TODO (1)" {
+		t.Errorf("Expected a column per state in ls_state_order order:\n%s", output)
+	}
+	cards := strings.Split(lines[2], "│")
+	if !strings.Contains(cards[0], "2 Fix login bug") || !strings.Contains(cards[2], "1 P1 Write release notes") ||
+		!strings.Contains(cards[4], "3 Ship it") {
+		t.Errorf("Expected cards with ids and priority badges in their columns:\n%s", output)
+	}
+
+	output, _ = executeCommand("board", "open", "--hide-empty")
+	if strings.Contains(output, "DONE") || strings.Contains(output, "CONFIRM") || !strings.Contains(output, "TODO (1)") {
+		t.Errorf("Expected the filter and --hide-empty to leave only open columns with cards:\n%s", output)
+	}
+
+	t.Setenv("COLUMNS", "40")
+	output, _ = executeCommand("board")
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if n := utf8.RuneCountInString(line); n > 40 && !strings.HasPrefix(line, "Not shown") {
+			t.Errorf("Expected lines to fit 40 columns, got %d: %q", n, line)
+		}
+	}
+	if !strings.Contains(output, "BEGUN (1)") || !strings.Contains(output, "TODO (1)") || !strings.Contains(output, "Not shown: DONE (1), BLOCK (0)") {
+		t.Errorf("Expected columns with cards drawn first and the rest listed:\n%s", output)
+	}
+}
+
+// test that a narrow board draws columns with cards ahead of empty ones
+func TestBoardNarrowSkipsEmptyColumns(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	t.Setenv("COLUMNS", "40")
+
+	executeCommand("init")
+	for _, title := range []string{"Write notes", "Fix login", "Ship it", "Tag release"} {
+		executeCommand("todo", title)
+	}
+
+	output, _ := executeCommand("board")
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if !strings.Contains(lines[0], "TODO (4)") || strings.Contains(lines[0], "BEGUN") || strings.Contains(lines[0], "BLOCK") {
+		t.Errorf("Expected the TODO column drawn instead of empty BEGUN and BLOCK:\n%s", output)
+	}
+	for _, title := range []string{"Write notes", "Fix login", "Ship it", "Tag release"} {
+		if !strings.Contains(output, title) {
+			t.Errorf("Expected a card for %q:\n%s", title, output)
+		}
+	}
+	if !strings.Contains(output, "Not shown: BEGUN (0), BLOCK (0)") || strings.Contains(output, "TODO (4),") {
+		t.Errorf("Expected only empty columns to be left out:\n%s", output)
+	}
+}
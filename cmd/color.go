@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"punchlist/task"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// values for the --color flag
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// the --color setting for this run
+var colorMode = colorAuto
+
+// ansi codes by name
+var ansiCodes = map[string]string{
+	"bold":    "1",
+	"dim":     "2",
+	"reverse": "7",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+	"gray":    "90",
+	"grey":    "90",
+}
+
+// ansi sequences for highlighted text
+const (
+	ansiHighlight = "\x1b[1;33m"
+	ansiReset     = "\x1b[0m"
+)
+
+// set the --color mode, rejecting unknown values
+func setColorMode(mode string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "":
+		colorMode = colorAuto
+	case colorAuto, colorAlways, colorNever:
+		colorMode = mode
+	default:
+		return fmt.Errorf("invalid --color %q (use auto, always, or never)", mode)
+	}
+	return nil
+}
+
+// report whether stdout should get ansi colors: --color always or never
+// decides, otherwise only on a terminal and never when NO_COLOR is set
+func colorEnabled() bool {
+	switch colorMode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return stdoutIsTerminal()
+}
+
+// report whether stdout is an interactive terminal
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// width of the terminal on stdout, or COLUMNS, or 0 when unknown
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+	return 0
+}
+
+// wrap text in the named ansi styles when enabled
+func paint(text string, enabled bool, styles ...string) string {
+	if !enabled || text == "" {
+		return text
+	}
+	codes := make([]string, 0, len(styles))
+	for _, style := range styles {
+		if code, ok := ansiCodes[style]; ok {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return text
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + text + ansiReset
+}
+
+// styles for a state name
+func stateStyles(state task.State) []string {
+	switch state {
+	case task.StateTodo:
+		return []string{"cyan"}
+	case task.StateBegun:
+		return []string{"yellow"}
+	case task.StateBlock:
+		return []string{"red"}
+	case task.StateConfirm:
+		return []string{"magenta"}
+	case task.StateDone:
+		return []string{"green"}
+	case task.StateNotDo:
+		return []string{"gray"}
+	}
+	if task.ActiveWorkflow().IsClosed(state) {
+		return []string{"gray"}
+	}
+	return []string{"blue"}
+}
+
+// styles for a priority; 1 is the most urgent
+func priorityStyles(priority int) []string {
+	switch priority {
+	case 1:
+		return []string{"bold", "red"}
+	case 2:
+		return []string{"yellow"}
+	}
+	return nil
+}
+
+// styles for a due date: red when overdue, yellow when due today;
+// closed tasks are never overdue
+func dueStyles(days int, closed bool) []string {
+	switch {
+	case closed:
+		return nil
+	case days < 0:
+		return []string{"bold", "red"}
+	case days == 0:
+		return []string{"yellow"}
+	}
+	return nil
+}
+
+// wrap byte ranges of text in highlight codes
+func highlightRanges(text string, ranges [][2]int, enabled bool) string {
+	if !enabled || len(ranges) == 0 {
+		return text
+	}
+	out := make([]byte, 0, len(text)+len(ranges)*(len(ansiHighlight)+len(ansiReset)))
+	last := 0
+	for _, r := range ranges {
+		out = append(out, text[last:r[0]]...)
+		out = append(out, ansiHighlight...)
+		out = append(out, text[r[0]:r[1]]...)
+		out = append(out, ansiReset...)
+		last = r[1]
+	}
+	out = append(out, text[last:]...)
+	return string(out)
+}
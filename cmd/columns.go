@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"punchlist/task"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// short names accepted for --columns
+var columnAliases = map[string]string{
+	"pri":       "priority",
+	"tag":       "tags",
+	"created":   "created_at",
+	"updated":   "updated_at",
+	"started":   "started_at",
+	"completed": "completed_at",
+	"ref":       "external_refs",
+	"refs":      "external_refs",
+}
+
+// add the --columns flag
+func addColumnsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("columns", []string{}, "Columns for text, csv and tsv output, e.g. id,state,title,due")
+}
+
+// read and validate the --columns flag
+func columnsFlag(cmd *cobra.Command) ([]string, error) {
+	raw, _ := cmd.Flags().GetStringSlice("columns")
+	return parseColumns(raw)
+}
+
+// normalize column names to record field names
+func parseColumns(names []string) ([]string, error) {
+	columns := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if alias, ok := columnAliases[name]; ok {
+			name = alias
+		}
+		if !isRecordColumn(name) {
+			return nil, fmt.Errorf("unknown column %q (use %s)", name, strings.Join(recordColumns, ", "))
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// report whether name is a record field
+func isRecordColumn(name string) bool {
+	for _, column := range recordColumns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
+
+// one task field as display text
+func textColumnValue(t *task.Task, column string) string {
+	switch column {
+	case "id":
+		return strconv.Itoa(t.ID)
+	case "title":
+		return t.Title
+	case "state":
+		return string(t.State)
+	case "priority":
+		if t.Priority == 0 {
+			return "-"
+		}
+		return strconv.Itoa(t.Priority)
+	case "due":
+		if t.Due == nil {
+			return "-"
+		}
+		return formatDueDate(t.Due)
+	case "tags":
+		return formatList(t.Tags)
+	case "created_at":
+		return t.CreatedAt.Format("2006-01-02 15:04")
+	case "updated_at":
+		return t.UpdatedAt.Format("2006-01-02 15:04")
+	case "started_at":
+		return formatColumnTime(t.StartedAt)
+	case "completed_at":
+		return formatColumnTime(t.CompletedAt)
+	case "external_refs":
+		return formatList(t.ExternalRefs)
+	case "path":
+		return t.Path()
+	}
+	return ""
+}
+
+// render optional timestamps for columns
+func formatColumnTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
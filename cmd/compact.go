@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"punchlist/config"
+	"punchlist/fsutil"
 	"punchlist/task"
 	"sort"
 	"strings"
@@ -28,7 +29,7 @@ func newCompactCmd() *cobra.Command {
 		Use:   "compact",
 		Short: "Compact task IDs into a contiguous sequence",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := compactTasks(); err != nil {
+			if err := withProjectLock(compactTasks); err != nil {
 				if printNotPunchlistError(err) {
 					return
 				}
@@ -74,7 +75,7 @@ func compactTasks() error {
 	// rename to temp files to avoid collisions
 	for i := range entries {
 		tempPath := compactTempPath(entries[i].oldPath)
-		if err := os.Rename(entries[i].oldPath, tempPath); err != nil {
+		if err := fsutil.Rename(entries[i].oldPath, tempPath); err != nil {
 			return fmt.Errorf("failed to stage %s: %w", entries[i].oldPath, err)
 		}
 		entries[i].tempPath = tempPath
@@ -86,7 +87,7 @@ func compactTasks() error {
 		entry := &entries[i]
 		if entry.oldID == entry.newID {
 			// keep file as-is but move back to original path
-			if err := os.Rename(entry.tempPath, entry.oldPath); err != nil {
+			if err := fsutil.Rename(entry.tempPath, entry.oldPath); err != nil {
 				return fmt.Errorf("failed to restore %s: %w", entry.oldPath, err)
 			}
 			continue
@@ -100,7 +101,7 @@ func compactTasks() error {
 		if err := entry.task.Write(newPath); err != nil {
 			return fmt.Errorf("failed to write %s: %w", newPath, err)
 		}
-		if err := os.Remove(entry.tempPath); err != nil {
+		if err := fsutil.Remove(entry.tempPath); err != nil {
 			return fmt.Errorf("failed to remove temp file %s: %w", entry.tempPath, err)
 		}
 	}
@@ -184,20 +185,10 @@ func compactTempPath(oldPath string) string {
 	dir := filepath.Dir(oldPath)
 	base := filepath.Base(oldPath)
 	stamp := time.Now().UnixNano()
-	return filepath.Join(dir, fmt.Sprintf(".compact-%d-%s", stamp, base))
+	return filepath.Join(dir, fmt.Sprintf("%s%d-%s", compactTempPrefix, stamp, base))
 }
 
 // append a log entry describing the id change
 func appendCompactLog(body string, oldID int, newID int, now time.Time) string {
-	entry := fmt.Sprintf("- %s: compacted id from %d to %d", now.Format(time.RFC3339), oldID, newID)
-
-	pre, logSection, afterLog, found := splitSection(body, "## Log")
-	if found {
-		pre += afterLog
-	} else {
-		logSection = "## Log"
-	}
-
-	logSection = appendEntry(logSection, entry)
-	return joinBlocks(pre, logSection)
+	return appendLogEntry(body, fmt.Sprintf("compacted id from %d to %d", oldID, newID), now)
 }
@@ -12,22 +12,34 @@ import (
 	"punchlist/task"
 )
 
-// canonical state tokens for shell completion
-var stateCompletionCandidates = []string{
-	"TODO",
-	"BEGUN",
-	"BLOCK",
-	"CONFIRM",
-	"DONE",
-	"NOTDO",
+// canonical state tokens for shell completion, including custom states
+func stateCompletionCandidates() []string {
+	names := task.ActiveWorkflow().Names()
+	candidates := make([]string, 0, len(names))
+	for _, state := range names {
+		candidates = append(candidates, string(state))
+	}
+	return candidates
 }
 
-// complete a single state argument for commands like ls
-func stateArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+// complete the first ls argument with states and saved views
+func lsArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
 	if len(args) > 0 {
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
-	return stateCompletions(toComplete), cobra.ShellCompDirectiveNoFileComp
+	if strings.HasPrefix(toComplete, viewPrefix) {
+		completions := projectCompletions(toComplete)
+		if !strings.Contains(toComplete, ",") {
+			completions = append(viewCompletions(toComplete), completions...)
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := stateCompletions(toComplete)
+	if toComplete == "" {
+		completions = append(completions, viewCompletions("")...)
+		completions = append(completions, projectCompletions("")...)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
 }
 
 // complete root args, with dynamic ids after certain states
@@ -58,13 +70,14 @@ func rootArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]
 
 // filter state completions by prefix
 func stateCompletions(toComplete string) []cobra.Completion {
+	candidates := stateCompletionCandidates()
 	if toComplete == "" {
-		return stringsToCompletions(stateCompletionCandidates)
+		return stringsToCompletions(candidates)
 	}
 
 	upper := strings.ToUpper(toComplete)
-	filtered := make([]string, 0, len(stateCompletionCandidates))
-	for _, candidate := range stateCompletionCandidates {
+	filtered := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
 		if strings.HasPrefix(candidate, upper) {
 			filtered = append(filtered, candidate)
 		}
@@ -142,3 +155,13 @@ func loadTasksByState() map[task.State][]*task.Task {
 
 	return tasksByState
 }
+
+// complete ids for move, then the target state once an id is given
+func moveArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return taskIDCompletions(task.ActiveWorkflow().Names(), toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := stateCompletions(toComplete)
+	completions = append(completions, taskIDCompletions(task.ActiveWorkflow().Names(), toComplete)...)
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"punchlist/config"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -13,15 +14,22 @@ func newConfigCmd() *cobra.Command {
 		Use:   "config",
 		Short: "Show the current configuration",
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg, err := config.LoadConfig()
+			root, source, err := config.ResolveRoot()
 			if err != nil {
 				if printNotPunchlistError(err) {
 					return
 				}
+				fmt.Printf("Error locating punchlist: %v\n", err)
+				return
+			}
+			cfg, err := config.LoadConfigFrom(root)
+			if err != nil {
 				fmt.Printf("Error loading config: %v\n", err)
 				return
 			}
+			fmt.Printf("Root: %s (from %s)\n", root, source)
 			fmt.Printf("Next ID: %d\n", cfg.NextID)
+			fmt.Printf("States: %s\n", strings.Join(stateCompletionCandidates(), ", "))
 		},
 	}
 	return cmd
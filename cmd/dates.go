@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// whole calendar days from now until t, in local time
+func daysUntil(t, now time.Time) int {
+	t = t.In(now.Location())
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location())
+	// round so daylight saving shifts don't lose a day
+	return int(math.Round(end.Sub(start).Hours() / 24))
+}
+
+// describe a date relative to today, like "tomorrow", "in 3d", or "2w ago"
+func relativeDay(t, now time.Time) string {
+	days := daysUntil(t, now)
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	case -1:
+		return "yesterday"
+	}
+	span := days
+	if span < 0 {
+		span = -span
+	}
+	amount := fmt.Sprintf("%dd", span)
+	switch {
+	case span >= 60:
+		amount = fmt.Sprintf("%dmo", span/30)
+	case span >= 14:
+		amount = fmt.Sprintf("%dw", span/7)
+	}
+	if days < 0 {
+		return amount + " ago"
+	}
+	return "in " + amount
+}
+
+// describe how far a due date is from today, like "3d overdue" or "in 2d"
+func dueRelative(due, now time.Time) string {
+	days := daysUntil(due, now)
+	switch {
+	case days < 0:
+		return fmt.Sprintf("%dd overdue", -days)
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "tomorrow"
+	}
+	return fmt.Sprintf("in %dd", days)
+}
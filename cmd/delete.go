@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"punchlist/config"
+	"punchlist/fsutil"
+	"punchlist/task"
 	"strings"
 	"time"
 
@@ -31,13 +35,22 @@ func newDeleteCmd() *cobra.Command {
 
 // delete multiple tasks, reporting errors per id
 func deleteTasks(ids []int) {
-	for _, id := range ids {
-		if err := deleteTaskSingle(id); err != nil {
-			if printNotPunchlistError(err) {
-				return
+	err := withProjectLock(func() error {
+		for _, id := range ids {
+			if err := deleteTaskSingle(id); err != nil {
+				if errors.Is(err, config.ErrPunchlistNotFound) {
+					return err
+				}
+				fmt.Printf("Error deleting task %d: %v\n", id, err)
 			}
-			fmt.Printf("Error deleting task %d: %v\n", id, err)
 		}
+		return nil
+	})
+	if err != nil {
+		if printNotPunchlistError(err) {
+			return
+		}
+		fmt.Printf("Error deleting tasks: %v\n", err)
 	}
 }
 
@@ -61,9 +74,17 @@ func deleteTaskSingle(id int) error {
 		destPath = uniqueTrashPath(destPath)
 	}
 
-	if err := os.Rename(taskPath, destPath); err != nil {
+	// read the title now, while the task is still where it was
+	title := ""
+	if t, err := task.Parse(taskPath); err == nil {
+		title = t.Title
+	}
+	if err := fsutil.Rename(taskPath, destPath); err != nil {
 		return fmt.Errorf("failed to move task to trash: %w", err)
 	}
+	if err := writeTrashMeta(destPath, trashMeta{ID: id, Title: title, DeletedAt: time.Now(), OriginalPath: taskPath}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record where task %d came from: %v\n", id, err)
+	}
 
 	fmt.Printf("Moved task %d to %s\n", id, destPath)
 	return nil
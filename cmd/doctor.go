@@ -0,0 +1,388 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/fsutil"
+	"punchlist/task"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// prefix compactTasks gives files while it renumbers them
+const compactTempPrefix = ".compact-"
+
+// a single problem found by doctor
+type doctorIssue struct {
+	kind   string
+	detail string
+	fixed  bool
+	fixErr error
+}
+
+// collected doctor findings
+type doctorReport struct {
+	issues []*doctorIssue
+}
+
+// record a problem and return it so a fix can be noted later
+func (r *doctorReport) add(kind, format string, args ...any) *doctorIssue {
+	issue := &doctorIssue{kind: kind, detail: fmt.Sprintf(format, args...)}
+	r.issues = append(r.issues, issue)
+	return issue
+}
+
+// note the outcome of a repair attempt
+func (i *doctorIssue) resolve(err error) {
+	i.fixed = err == nil
+	i.fixErr = err
+}
+
+// count problems that are still present
+func (r *doctorReport) unresolved() int {
+	count := 0
+	for _, issue := range r.issues {
+		if !issue.fixed {
+			count++
+		}
+	}
+	return count
+}
+
+// create the doctor command
+func newDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "doctor",
+		Short:         "Check a punchlist for problems and optionally repair them",
+		Long:          `Check task files, ids, filenames, and config for problems left by hand edits, merges, or interrupted commands. With --fix, repair what can be repaired safely.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fix, _ := cmd.Flags().GetBool("fix")
+
+			var report *doctorReport
+			run := func() error {
+				var err error
+				report, err = runDoctor(fix)
+				return err
+			}
+			var err error
+			if fix {
+				err = withProjectLock(run)
+			} else {
+				err = run()
+			}
+			if err != nil {
+				if printNotPunchlistError(err) {
+					return exitError{code: 1}
+				}
+				fmt.Printf("Error checking punchlist: %v\n", err)
+				return exitError{code: 1}
+			}
+
+			printDoctorReport(report, fix)
+			if report.unresolved() > 0 {
+				return exitError{code: 1}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("fix", false, "Repair problems that can be fixed safely")
+	return cmd
+}
+
+// run every check, repairing as it goes when fix is set
+func runDoctor(fix bool) (*doctorReport, error) {
+	root, err := punchlistRoot()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadConfigFrom(root)
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	tasksPath := filepath.Join(root, "tasks")
+	report := &doctorReport{}
+
+	// leftovers from interrupted writes come first since they hide tasks
+	checkInterruptedWrites(report, root, fix)
+	idx, err := loadTaskIndex(tasksPath)
+	if err != nil {
+		return nil, err
+	}
+	checkCompactLeftovers(report, tasksPath, idx, fix)
+
+	// reload so restored files take part in the remaining checks
+	idx, err = loadTaskIndex(tasksPath)
+	if err != nil {
+		return nil, err
+	}
+	checkUnparseable(report, idx)
+	checkStates(report, idx, fix)
+	checkDuplicateIDs(report, idx, cfg, fix)
+	checkFilenames(report, idx, cfg, fix)
+	checkNextID(report, root, idx, cfg, fix)
+
+	return report, nil
+}
+
+// print findings, one per line
+func printDoctorReport(report *doctorReport, fix bool) {
+	if len(report.issues) == 0 {
+		fmt.Println("No problems found.")
+		return
+	}
+	for _, issue := range report.issues {
+		status := "problem"
+		if issue.fixed {
+			status = "fixed"
+		}
+		fmt.Printf("[%s] %s: %s\n", status, issue.kind, issue.detail)
+		if issue.fixErr != nil {
+			fmt.Printf("        could not fix: %v\n", issue.fixErr)
+		}
+	}
+
+	remaining := report.unresolved()
+	fmt.Printf("Found %d problem(s), %d remaining.\n", len(report.issues), remaining)
+	if remaining > 0 && !fix {
+		fmt.Println("Run pin doctor --fix to repair what can be fixed safely.")
+	}
+}
+
+// report temp files left by interrupted atomic writes
+func checkInterruptedWrites(report *doctorReport, root string, fix bool) {
+	for _, dir := range []string{filepath.Join(root, "tasks"), filepath.Join(root, config.PunchlistDir)} {
+		orphans, err := fsutil.FindOrphans(dir)
+		if err != nil {
+			report.add("unreadable directory", "%s: %v", dir, err)
+			continue
+		}
+		for _, orphan := range orphans {
+			if !orphan.TargetExists {
+				report.add("interrupted write", "%s never replaced %s; check it and rename it into place if complete",
+					orphan.Path, filepath.Base(orphan.Target))
+				continue
+			}
+			issue := report.add("interrupted write", "stale temp file %s", orphan.Path)
+			if fix {
+				issue.resolve(os.Remove(orphan.Path))
+			}
+		}
+	}
+}
+
+// report files staged by a compact that never finished
+func checkCompactLeftovers(report *doctorReport, tasksPath string, idx *taskIndex, fix bool) {
+	files, err := os.ReadDir(tasksPath)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasPrefix(name, compactTempPrefix) {
+			continue
+		}
+		stagedPath := filepath.Join(tasksPath, name)
+		issue := report.add("interrupted compact", "%s was staged by pin compact and never finished", name)
+		if !fix {
+			continue
+		}
+
+		staged, err := task.Parse(stagedPath)
+		if err != nil {
+			issue.resolve(fmt.Errorf("could not parse staged file: %w", err))
+			continue
+		}
+		// compact already wrote this task under its new id
+		if idx.hasTaskLike(staged) {
+			issue.resolve(fsutil.Remove(stagedPath))
+			continue
+		}
+		original := compactOriginalName(name)
+		if original == "" {
+			issue.resolve(fmt.Errorf("could not recover original filename"))
+			continue
+		}
+		originalPath := filepath.Join(tasksPath, original)
+		if _, err := os.Stat(originalPath); err == nil {
+			issue.resolve(fmt.Errorf("%s already exists", original))
+			continue
+		}
+		issue.resolve(fsutil.Rename(stagedPath, originalPath))
+	}
+}
+
+// strip the compact staging prefix and timestamp from a filename
+func compactOriginalName(name string) string {
+	rest := strings.TrimPrefix(name, compactTempPrefix)
+	parts := strings.SplitN(rest, "-", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return ""
+	}
+	return parts[1]
+}
+
+// report whether the index holds the same task under any id
+func (idx *taskIndex) hasTaskLike(t *task.Task) bool {
+	for _, entry := range idx.entries {
+		if entry.parseErr != nil {
+			continue
+		}
+		if entry.task.Title == t.Title && entry.task.CreatedAt.Equal(t.CreatedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// report files whose frontmatter can't be read
+func checkUnparseable(report *doctorReport, idx *taskIndex) {
+	for _, entry := range idx.entries {
+		if entry.parseErr != nil {
+			report.add("unparseable frontmatter", "%s: %v", filepath.Base(entry.path), entry.parseErr)
+		}
+	}
+}
+
+// report states pin doesn't know, fixing ones that are just an alias
+func checkStates(report *doctorReport, idx *taskIndex, fix bool) {
+	for _, entry := range idx.entries {
+		if entry.parseErr != nil {
+			continue
+		}
+		if parsed, ok := task.ParseState(string(entry.task.State)); ok && parsed == entry.task.State {
+			continue
+		}
+		canonical, known := normalizeOrderLabel(string(entry.task.State))
+		if !known {
+			report.add("unknown state", "%s has state %q", filepath.Base(entry.path), entry.task.State)
+			continue
+		}
+		issue := report.add("unknown state", "%s has state %q, which means %s", filepath.Base(entry.path), entry.task.State, canonical)
+		if fix {
+			entry.task.State = task.State(canonical)
+			entry.task.UpdatedAt = time.Now()
+			issue.resolve(entry.task.Write(entry.path))
+		}
+	}
+}
+
+// report ids claimed by more than one file, renumbering all but one
+func checkDuplicateIDs(report *doctorReport, idx *taskIndex, cfg *config.Config, fix bool) {
+	used := idx.usedIDs()
+	nextFree := func() int {
+		id := cfg.NextID
+		if id < 1 {
+			id = 1
+		}
+		for used[id] {
+			id++
+		}
+		used[id] = true
+		return id
+	}
+
+	for _, id := range idx.duplicateIDs() {
+		entries := append([]*taskFileEntry{}, idx.byID[id]...)
+		// keep the file whose name agrees with the id, then the oldest
+		sort.SliceStable(entries, func(i, j int) bool {
+			iMatch := entries[i].hasPrefix && entries[i].prefixID == id
+			jMatch := entries[j].hasPrefix && entries[j].prefixID == id
+			if iMatch != jMatch {
+				return iMatch
+			}
+			if !entries[i].task.CreatedAt.Equal(entries[j].task.CreatedAt) {
+				return entries[i].task.CreatedAt.Before(entries[j].task.CreatedAt)
+			}
+			return entries[i].path < entries[j].path
+		})
+
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, filepath.Base(entry.path))
+		}
+		issue := report.add("duplicate id", "id %d is used by %s", id, strings.Join(names, ", "))
+		if !fix {
+			continue
+		}
+
+		var fixErr error
+		now := time.Now()
+		for _, entry := range entries[1:] {
+			newID := nextFree()
+			entry.task.ID = newID
+			entry.task.UpdatedAt = now
+			entry.task.Body = appendLogEntry(entry.task.Body, fmt.Sprintf("renumbered from duplicate id %d to %d", id, newID), now)
+			if err := entry.task.Write(entry.path); err != nil {
+				fixErr = err
+				break
+			}
+		}
+		issue.resolve(fixErr)
+		if fixErr == nil {
+			idx.byID[id] = entries[:1]
+			for _, entry := range entries[1:] {
+				idx.byID[entry.task.ID] = []*taskFileEntry{entry}
+			}
+		}
+	}
+}
+
+// report filenames whose id or slug disagrees with frontmatter
+func checkFilenames(report *doctorReport, idx *taskIndex, cfg *config.Config, fix bool) {
+	idWidth := idWidthFromConfig(cfg)
+	for _, entry := range idx.entries {
+		if entry.parseErr != nil {
+			continue
+		}
+		name := filepath.Base(entry.path)
+		slug := slugify(entry.task.Title)
+		idMatches := entry.hasPrefix && entry.prefixID == entry.task.ID
+		slugMatches := compactSuffix(name, entry.task.Title) == slug
+		if idMatches && slugMatches {
+			continue
+		}
+
+		expected := fmt.Sprintf("%0*d-%s.md", idWidth, entry.task.ID, slug)
+		issue := report.add("filename mismatch", "%s should be %s", name, expected)
+		if !fix {
+			continue
+		}
+		target := filepath.Join(filepath.Dir(entry.path), expected)
+		if _, err := os.Stat(target); err == nil {
+			issue.resolve(fmt.Errorf("%s already exists", expected))
+			continue
+		}
+		if err := fsutil.Rename(entry.path, target); err != nil {
+			issue.resolve(err)
+			continue
+		}
+		entry.path = target
+		issue.resolve(nil)
+	}
+}
+
+// report a next_id that would hand out an id already in use
+func checkNextID(report *doctorReport, root string, idx *taskIndex, cfg *config.Config, fix bool) {
+	highest := 0
+	for id := range idx.usedIDs() {
+		if id > highest {
+			highest = id
+		}
+	}
+	if cfg.NextID > highest {
+		return
+	}
+
+	issue := report.add("stale next_id", "next_id is %d but the highest task id is %d", cfg.NextID, highest)
+	if fix {
+		cfg.NextID = highest + 1
+		issue.resolve(config.SaveConfigTo(root, cfg))
+	}
+}
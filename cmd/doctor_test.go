@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/task"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test doctor reporting and repairs
+func TestDoctorCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	tasksPath, err := tasksDir()
+	if err != nil {
+		t.Fatalf("Failed to resolve tasks dir: %v", err)
+	}
+
+	output, err := executeCommand("doctor")
+	if err != nil {
+		t.Fatalf("doctor on a clean project failed: %v", err)
+	}
+	if !strings.Contains(output, "No problems found.") {
+		t.Errorf("Expected a clean report, got: %s", output)
+	}
+
+	created := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	tasks := map[string]*task.Task{
+		"001-first.md":        {ID: 1, Title: "First", State: task.StateTodo, CreatedAt: created},
+		"001-copy.md":         {ID: 1, Title: "Copy", State: task.StateTodo, CreatedAt: created.Add(time.Hour)},
+		"002-old-name.md":     {ID: 2, Title: "New Name", State: "blocked", CreatedAt: created},
+		"003-mystery.md":      {ID: 3, Title: "Mystery", State: "LIMBO", CreatedAt: created},
+		".compact-1-004-x.md": {ID: 4, Title: "X", State: task.StateTodo, CreatedAt: created},
+	}
+	for name, tk := range tasks {
+		if err := tk.Write(filepath.Join(tasksPath, name)); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	os.WriteFile(filepath.Join(tasksPath, "005-broken.md"), []byte("---\nid: [\n---\n"), 0644)
+
+	output, err = executeCommand("doctor")
+	var exitErr exitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Expected doctor to fail with problems, got %v", err)
+	}
+	for _, kind := range []string{"duplicate id", "filename mismatch", "unknown state", "interrupted compact", "unparseable frontmatter", "stale next_id"} {
+		if !strings.Contains(output, kind) {
+			t.Errorf("Expected doctor to report %q. Got: %s", kind, output)
+		}
+	}
+
+	output, _ = executeCommand("doctor", "--fix")
+	t.Logf("doctor --fix output:\n%s", output)
+
+	if _, err := os.Stat(filepath.Join(tasksPath, "004-x.md")); err != nil {
+		t.Errorf("Expected staged compact file to be restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tasksPath, "002-new-name.md")); err != nil {
+		t.Errorf("Expected mismatched filename to be renamed: %v", err)
+	}
+	renamed, err := task.Parse(filepath.Join(tasksPath, "002-new-name.md"))
+	if err != nil || renamed.State != task.StateBlock {
+		t.Errorf("Expected state alias to be normalized to BLOCK, got %v (%v)", renamed, err)
+	}
+
+	idx, err := loadTaskIndex(tasksPath)
+	if err != nil {
+		t.Fatalf("Failed to load index: %v", err)
+	}
+	if dups := idx.duplicateIDs(); len(dups) != 0 {
+		t.Errorf("Expected duplicates to be renumbered, still have %v", dups)
+	}
+	first, err := idx.resolve(1)
+	if err != nil || first.task.Title != "First" {
+		t.Errorf("Expected the original task to keep id 1, got %v (%v)", first, err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	highest := 0
+	for id := range idx.usedIDs() {
+		if id > highest {
+			highest = id
+		}
+	}
+	if cfg.NextID <= highest {
+		t.Errorf("Expected next_id above %d, got %d", highest, cfg.NextID)
+	}
+
+	// only the unfixable problems remain
+	output, _ = executeCommand("doctor")
+	if !strings.Contains(output, "unparseable frontmatter") || !strings.Contains(output, "LIMBO") {
+		t.Errorf("Expected unfixable problems to remain. Got: %s", output)
+	}
+	if strings.Contains(output, "duplicate id") || strings.Contains(output, "filename mismatch") {
+		t.Errorf("Expected fixed problems to be gone. Got: %s", output)
+	}
+}
@@ -33,51 +33,50 @@ func newDueCmd() *cobra.Command {
 				return
 			}
 
-			taskPath, err := findTaskFile(id)
-			if err != nil {
+			if err := withProjectLock(func() error { return setTaskDue(id, dueTime) }); err != nil {
 				if printNotPunchlistError(err) {
 					return
 				}
-				fmt.Printf("Error finding task: %v\n", err)
+				fmt.Printf("Error updating task: %v\n", err)
 				return
 			}
 
-			t, err := task.Parse(taskPath)
-			if err != nil {
-				fmt.Printf("Error parsing task: %v\n", err)
-				return
-			}
+			fmt.Printf("Updated due date for task %d\n", id)
+		},
+	}
+}
+
+// set a task's due date and log the change
+func setTaskDue(id int, dueTime *time.Time) error {
+	taskPath, err := findTaskFile(id)
+	if err != nil {
+		return err
+	}
 
-			// update task and append a log entry
-			now := time.Now()
-			prevDue := t.Due
-			t.Due = dueTime
-			t.UpdatedAt = now
+	t, err := task.Parse(taskPath)
+	if err != nil {
+		return fmt.Errorf("error parsing task: %w", err)
+	}
 
-			dueText := dueTime.Format(time.RFC3339)
-			var msg string
-			if prevDue == nil {
-				msg = fmt.Sprintf("added due date: %s", dueText)
-			} else {
-				msg = fmt.Sprintf("due date changed to: %s", dueText)
-			}
-			logEntry := fmt.Sprintf("- %s: %s", now.Format(time.RFC3339), msg)
+	// update task and append a log entry
+	now := time.Now()
+	prevDue := t.Due
+	t.Due = dueTime
+	t.UpdatedAt = now
 
-			pre, logSection, afterLog, found := splitSection(t.Body, "## Log")
-			if found {
-				pre += afterLog
-			} else {
-				logSection = "## Log"
-			}
-			logSection = appendEntry(logSection, logEntry)
-			t.Body = joinBlocks(pre, logSection)
+	t.Body = appendLogEntry(t.Body, dueLogMessage(prevDue, dueTime), now)
 
-			if err := t.Write(taskPath); err != nil {
-				fmt.Printf("Error updating task: %v\n", err)
-				return
-			}
+	return t.Write(taskPath)
+}
 
-			fmt.Printf("Updated due date for task %d\n", id)
-		},
+// describe a due date change for the task log, where nil means no due date
+func dueLogMessage(from, to *time.Time) string {
+	switch {
+	case to == nil:
+		return fmt.Sprintf("due date cleared (was %s)", from.Format(time.RFC3339))
+	case from == nil:
+		return fmt.Sprintf("added due date: %s", to.Format(time.RFC3339))
+	default:
+		return fmt.Sprintf("due date changed to: %s", to.Format(time.RFC3339))
 	}
 }
@@ -0,0 +1,392 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/fsutil"
+	"punchlist/task"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// task template used by pin edit --new unless one is named
+const defaultTaskTemplateName = "default"
+
+// extension of task templates, which share a folder with output templates
+const taskTemplateExt = ".md"
+
+// body of a new task when the project has no default template
+const builtinTaskTemplate = "# {{.Title}}\n"
+
+// title pin edit --new gives a task when none is passed
+const untitledTaskTitle = "Untitled"
+
+// answers to questions, read from stdin unless a test swaps it
+var promptReader = bufio.NewReader(os.Stdin)
+
+// values a task template can use
+type taskTemplateData struct {
+	ID    int
+	Title string
+	Today string
+}
+
+// create the edit command
+func newEditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit <id>",
+		Short: "Open a task in your editor and check it when you're done",
+		Long: `Open a task file in $VISUAL or $EDITOR. When the editor exits the file is
+checked: the frontmatter must parse, the id must be unchanged, and the title
+and state must be valid. If it isn't, pin offers to reopen the editor, keep
+the file as written, or discard the changes.
+
+A valid edit bumps updated_at, records a state change in the task history, and
+renames the file when the title no longer matches its slug.
+
+Create a task and open it straight away with --new, from
+.punchlist/templates/default.md or a named template:
+  pin edit --new "Fix login bug"
+  pin edit --new --template bug "Crash on save"`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if isNew, _ := cmd.Flags().GetBool("new"); isNew || len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return taskIDCompletions(task.ActiveWorkflow().Names(), toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if isNew, _ := cmd.Flags().GetBool("new"); isNew {
+				templateName, _ := cmd.Flags().GetString("template")
+				err = editNewTask(strings.TrimSpace(strings.Join(args, " ")), templateName)
+			} else if len(args) != 1 {
+				fmt.Println("Usage: pin edit <id>, or pin edit --new [title]")
+				return
+			} else {
+				id, convErr := strconv.Atoi(args[0])
+				if convErr != nil {
+					fmt.Printf("Invalid task id: %s\n", args[0])
+					return
+				}
+				err = editTask(id)
+			}
+			if err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error editing task: %v\n", err)
+			}
+		},
+	}
+	cmd.Flags().Bool("new", false, "Create a task from a template and open it")
+	cmd.Flags().String("template", "", "Task template in .punchlist/templates to use with --new")
+	cmd.RegisterFlagCompletionFunc("template", taskTemplateCompletion)
+	return cmd
+}
+
+// open an existing task in the editor, then check and save it
+func editTask(id int) error {
+	path, err := findTaskFile(id)
+	if err != nil {
+		return err
+	}
+	return editTaskFile(id, path, false)
+}
+
+// create a task from a template and open it in the editor
+func editNewTask(title, templateName string) error {
+	if title == "" {
+		title = untitledTaskTitle
+	}
+	var created *task.Task
+	err := withProjectLock(func() error {
+		var err error
+		created, err = writeTaskFromTemplate(title, templateName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created task %d: %s\n", created.ID, created.Path())
+	return editTaskFile(created.ID, created.Path(), true)
+}
+
+// run the editor on path until the result is valid or the user gives up,
+// then save it; a task created for the edit is removed if discarded
+func editTaskFile(id int, path string, created bool) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// a file that doesn't parse can still be opened to repair it
+	before, _ := task.Parse(path)
+	if before != nil {
+		if state, ok := task.ParseState(string(before.State)); ok {
+			before.State = state
+		}
+	}
+
+	for {
+		if err := openEditor(path); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(edited, original) {
+			if !created {
+				fmt.Printf("No changes to task %d.\n", id)
+			}
+			return nil
+		}
+
+		t, checkErr := checkEditedTask(path, id, before)
+		if checkErr == nil {
+			return saveEditedTask(t, before, original)
+		}
+
+		fmt.Printf("Task %d is invalid: %v\n", id, checkErr)
+		answer, err := promptLine("(e)dit again, (k)eep as written, or (d)iscard changes? [e] ")
+		if err != nil {
+			// nobody to ask, so leave the file for pin doctor to report
+			return fmt.Errorf("%s left as edited: %w", filepath.Base(path), checkErr)
+		}
+		switch strings.ToLower(answer) {
+		case "k", "keep":
+			fmt.Printf("Kept %s as written.\n", filepath.Base(path))
+			return nil
+		case "d", "discard":
+			if created {
+				if err := withProjectLock(func() error { return fsutil.Remove(path) }); err != nil {
+					return err
+				}
+				fmt.Printf("Discarded new task %d.\n", id)
+				return nil
+			}
+			// starting the journal from original records nothing, since the
+			// file ends up unchanged
+			err := withProjectLockFrom(map[string][]byte{path: original}, func() error {
+				return fsutil.WriteFile(path, original, 0644)
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Discarded changes to task %d.\n", id)
+			return nil
+		}
+	}
+}
+
+// parse an edited task file and explain what makes it invalid
+func checkEditedTask(path string, id int, before *task.Task) (*task.Task, error) {
+	t, err := task.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if t.ID == 0 {
+		return nil, fmt.Errorf("missing id in frontmatter")
+	}
+	if t.ID != id {
+		return nil, fmt.Errorf("id changed from %d to %d; use pin compact to renumber tasks", id, t.ID)
+	}
+	if strings.TrimSpace(t.Title) == "" {
+		return nil, fmt.Errorf("title is empty")
+	}
+	state, ok := task.ParseState(string(t.State))
+	if !ok {
+		return nil, fmt.Errorf("unknown state %q (known states: %s)", t.State, strings.Join(stateCompletionCandidates(), ", "))
+	}
+	t.State = state
+	if before != nil && before.State != state {
+		if err := task.ActiveWorkflow().CheckTransition(before.State, state); err != nil {
+			return nil, fmt.Errorf("%w (see transitions in %s/config.yaml)", err, config.PunchlistDir)
+		}
+	}
+	return t, nil
+}
+
+// bump updated_at, record a state change made in the editor, and rename the
+// file if the title slug changed; the journal starts from original, the file
+// as it was before the editor
+func saveEditedTask(t *task.Task, before *task.Task, original []byte) error {
+	return withProjectLockFrom(map[string][]byte{t.Path(): original}, func() error {
+		now := time.Now()
+		if before != nil && before.State != t.State {
+			edited := t.State
+			t.State = before.State
+			t.RecordTransition(edited, "", now)
+			t.Body = appendLogEntry(t.Body, transitionLogMessage(before.State, edited, ""), now)
+			if edited == task.StateBegun {
+				t.StartedAt = &now
+			} else if edited == task.StateDone {
+				t.CompletedAt = &now
+			}
+		}
+		t.UpdatedAt = now
+		if err := t.Write(t.Path()); err != nil {
+			return err
+		}
+		fmt.Printf("Updated task %d.\n", t.ID)
+
+		oldName := filepath.Base(t.Path())
+		renamed, err := renameTaskFile(t)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if renamed != t.Path() {
+			fmt.Printf("Renamed %s to %s\n", oldName, filepath.Base(renamed))
+		}
+		return nil
+	})
+}
+
+// rename a task's file to match its id and title slug, returning the new
+// path; a file already at that name is reported rather than replaced
+func renameTaskFile(t *task.Task) (string, error) {
+	path := t.Path()
+	name := filepath.Base(path)
+	slug := slugify(t.Title)
+	if compactSuffix(name, t.Title) == slug {
+		return path, nil
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return path, fmt.Errorf("error loading config: %w", err)
+	}
+	expected := fmt.Sprintf("%0*d-%s.md", idWidthFromConfig(cfg), t.ID, slug)
+	target := filepath.Join(filepath.Dir(path), expected)
+	if _, err := os.Stat(target); err == nil {
+		return path, fmt.Errorf("can't rename %s: %s already exists", name, expected)
+	}
+	if err := fsutil.Rename(path, target); err != nil {
+		return path, err
+	}
+	return target, nil
+}
+
+// write a new task from a template in .punchlist/templates, which may set
+// frontmatter such as priority and tags and use {{.ID}}, {{.Title}} and
+// {{.Today}}; id, title, state and timestamps are filled in when missing
+func writeTaskFromTemplate(title, name string) (*task.Task, error) {
+	text, err := loadTaskTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = defaultTaskTemplateName
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	id, filePath, err := newTaskPath(cfg, title)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var rendered bytes.Buffer
+	data := taskTemplateData{ID: id, Title: title, Today: now.Format("2006-01-02")}
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+
+	// parse the rendered template in place so its frontmatter keys survive
+	if err := fsutil.WriteFile(filePath, rendered.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("error writing task file: %w", err)
+	}
+	newTask, err := task.Parse(filePath)
+	if err != nil {
+		fsutil.Remove(filePath)
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	newTask.ID = id
+	if newTask.Title == "" {
+		newTask.Title = title
+	}
+	if newTask.State == "" {
+		newTask.State = task.StateTodo
+	} else if state, ok := task.ParseState(string(newTask.State)); ok {
+		newTask.State = state
+	} else {
+		fsutil.Remove(filePath)
+		return nil, fmt.Errorf("template %s: unknown state %q", name, newTask.State)
+	}
+	newTask.CreatedAt = now
+	newTask.UpdatedAt = now
+	if err := newTask.Write(filePath); err != nil {
+		return nil, fmt.Errorf("error writing task file: %w", err)
+	}
+
+	cfg.NextID = id + 1
+	if err := config.SaveConfig(cfg); err != nil {
+		return nil, fmt.Errorf("error saving config: %w", err)
+	}
+	return newTask, nil
+}
+
+// read a task template by name, falling back to the built-in one when no
+// name is given and the project has no default.md
+func loadTaskTemplate(name string) (string, error) {
+	dir, err := templatesDir()
+	if err != nil {
+		return "", err
+	}
+	lookup := strings.TrimSuffix(name, taskTemplateExt)
+	if lookup == "" {
+		lookup = defaultTaskTemplateName
+	}
+	if strings.ContainsAny(lookup, `/\`) {
+		return "", fmt.Errorf("invalid template name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, lookup+taskTemplateExt))
+	if os.IsNotExist(err) {
+		if name == "" {
+			return builtinTaskTemplate, nil
+		}
+		return "", fmt.Errorf("no task template %q in %s", name, dir)
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// complete --template with the project's task template names
+func taskTemplateCompletion(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	dir, err := templatesDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, _ := templateNames(dir, taskTemplateExt)
+	filtered := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) {
+			filtered = append(filtered, name)
+		}
+	}
+	return stringsToCompletions(filtered), cobra.ShellCompDirectiveNoFileComp
+}
+
+// print a question and read one line of answer, trimmed
+func promptLine(question string) (string, error) {
+	fmt.Print(question)
+	answer, err := promptReader.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Println()
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
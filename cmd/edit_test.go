@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/task"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// point $EDITOR at a script that replaces the file with each version in
+// turn, one per run, and answer prompts with answers
+func fakeEditor(t *testing.T, answers string, versions ...string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake editor is a shell script")
+	}
+	dir := t.TempDir()
+	for i, version := range versions {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("version-%d.md", i+1)), []byte(version), 0644); err != nil {
+			t.Fatalf("Failed to write editor version: %v", err)
+		}
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+n=$(cat %[1]s/count 2>/dev/null || echo 0)
+n=$((n + 1))
+echo $n > %[1]s/count
+cp %[1]s/version-$n.md "$1"
+`, dir)
+	editor := filepath.Join(dir, "editor.sh")
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake editor: %v", err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", editor)
+
+	saved := promptReader
+	promptReader = bufio.NewReader(strings.NewReader(answers))
+	t.Cleanup(func() { promptReader = saved })
+}
+
+// frontmatter for task 1 as a user might leave it after editing
+func editedTask(title, state string) string {
+	return fmt.Sprintf(`---
+id: 1
+title: %s
+state: %s
+created_at: 2026-01-01T09:00:00Z
+updated_at: 2026-01-01T09:00:00Z
+---
+
+# %s
+`, title, state, title)
+}
+
+// test that a valid edit is saved, renamed and recorded
+func TestEditTask(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	fakeEditor(t, "", editedTask("Fix signup bug", "begun"))
+
+	before := time.Now().Add(-time.Second)
+	output, err := executeCommand("edit", "1")
+	if err != nil {
+		t.Fatalf("edit failed: %v", err)
+	}
+	for _, expected := range []string{"Updated task 1.", "Renamed 001-fix-login-bug.md to 001-fix-signup-bug.md"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in output, got %q", expected, output)
+		}
+	}
+
+	path, err := findTaskFile(1)
+	if err != nil {
+		t.Fatalf("findTaskFile failed: %v", err)
+	}
+	if filepath.Base(path) != "001-fix-signup-bug.md" {
+		t.Errorf("Expected the file to follow the new title, got %s", path)
+	}
+	edited, err := task.Parse(path)
+	if err != nil {
+		t.Fatalf("Failed to parse edited task: %v", err)
+	}
+	if edited.State != task.StateBegun || edited.StartedAt == nil || len(edited.Transitions) != 1 {
+		t.Errorf("Expected the state change to be recorded, got %s with %d transitions", edited.State, len(edited.Transitions))
+	}
+	if edited.UpdatedAt.Before(before) {
+		t.Errorf("Expected updated_at to be bumped, got %s", edited.UpdatedAt)
+	}
+	if !strings.Contains(edited.Body, "state changed from TODO to BEGUN") {
+		t.Errorf("Expected a log entry for the state change:\n%s", edited.Body)
+	}
+}
+
+// test that closing the editor without changes leaves the file alone
+func TestEditTaskUnchanged(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	path, _ := findTaskFile(1)
+	original, _ := os.ReadFile(path)
+	fakeEditor(t, "", string(original))
+
+	output, _ := executeCommand("edit", "1")
+	if !strings.Contains(output, "No changes to task 1.") {
+		t.Errorf("Expected no changes, got %q", output)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(original) {
+		t.Errorf("Expected the file to be untouched, got:\n%s", after)
+	}
+}
+
+// test that invalid edits can be fixed in the editor or discarded
+func TestEditTaskInvalid(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	path, _ := findTaskFile(1)
+	original, _ := os.ReadFile(path)
+
+	t.Run("reopens the editor", func(t *testing.T) {
+		fakeEditor(t, "e\n", "---\nid: 1\ntitle: [broken\n---\n", editedTask("Fix login bug", "BLOCK"))
+		output, err := executeCommand("edit", "1")
+		if err != nil {
+			t.Fatalf("edit failed: %v", err)
+		}
+		if !strings.Contains(output, "Task 1 is invalid") || !strings.Contains(output, "Updated task 1.") {
+			t.Errorf("Expected the broken edit to be rejected then fixed, got %q", output)
+		}
+		edited, err := task.Parse(path)
+		if err != nil || edited.State != task.StateBlock {
+			t.Errorf("Expected the second edit to be saved, got %v %v", edited, err)
+		}
+		original, _ = os.ReadFile(path)
+	})
+
+	t.Run("discards an unknown state", func(t *testing.T) {
+		root, _ := punchlistRoot()
+		recorded, _ := journalNames(root)
+		fakeEditor(t, "d\n", editedTask("Fix login bug", "SOMEDAY"))
+		output, _ := executeCommand("edit", "1")
+		if !strings.Contains(output, `unknown state "SOMEDAY"`) || !strings.Contains(output, "Discarded changes to task 1.") {
+			t.Errorf("Expected the unknown state to be discarded, got %q", output)
+		}
+		if after, _ := os.ReadFile(path); string(after) != string(original) {
+			t.Errorf("Expected the original file back, got:\n%s", after)
+		}
+		if names, _ := journalNames(root); len(names) != len(recorded) {
+			t.Errorf("Expected nothing recorded for a discarded edit, got %d entries after %d", len(names), len(recorded))
+		}
+	})
+
+	t.Run("rejects a changed id", func(t *testing.T) {
+		fakeEditor(t, "k\n", strings.Replace(editedTask("Fix login bug", "BLOCK"), "id: 1", "id: 7", 1))
+		output, _ := executeCommand("edit", "1")
+		if !strings.Contains(output, "id changed from 1 to 7") || !strings.Contains(output, "Kept 001-fix-login-bug.md as written.") {
+			t.Errorf("Expected the id change to be reported and kept, got %q", output)
+		}
+	})
+}
+
+// test creating a task from a template and opening it
+func TestEditNewTask(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	template := `---
+priority: 2
+tags: [bug]
+reporter: ""
+---
+
+# {{.Title}}
+
+Filed {{.Today}} as task {{.ID}}.
+
+## Steps to reproduce
+`
+	templates := filepath.Join(".punchlist", "templates")
+	os.MkdirAll(templates, 0755)
+	if err := os.WriteFile(filepath.Join(templates, "bug.md"), []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	// an editor that changes nothing keeps the task as the template made it
+	fakeEditor(t, "")
+	t.Setenv("EDITOR", "true")
+	output, err := executeCommand("edit", "--new", "--template", "bug", "Crash", "on", "save")
+	if err != nil {
+		t.Fatalf("edit --new failed: %v", err)
+	}
+	if !strings.Contains(output, "Created task 2:") {
+		t.Errorf("Expected task 2 to be created, got %q", output)
+	}
+	path, err := findTaskFile(2)
+	if err != nil {
+		t.Fatalf("findTaskFile failed: %v", err)
+	}
+	created, err := task.Parse(path)
+	if err != nil {
+		t.Fatalf("Failed to parse created task: %v", err)
+	}
+	if created.Title != "Crash on save" || created.State != task.StateTodo || created.Priority != 2 || len(created.Tags) != 1 {
+		t.Errorf("Expected the template's fields with the given title, got %+v", created)
+	}
+	if _, ok := created.Extra()["reporter"]; !ok {
+		t.Errorf("Expected the template's extra keys to be kept, got %v", created.Extra())
+	}
+	if !strings.Contains(created.Body, "as task 2.") || !strings.Contains(created.Body, "## Steps to reproduce") {
+		t.Errorf("Expected the rendered template body, got:\n%s", created.Body)
+	}
+
+	// discarding a new task removes it
+	fakeEditor(t, "d\n", "---\nid: 3\ntitle: \"\"\nstate: TODO\n---\n")
+	output, _ = executeCommand("edit", "--new")
+	if !strings.Contains(output, "title is empty") || !strings.Contains(output, "Discarded new task 3.") {
+		t.Errorf("Expected the new task to be discarded, got %q", output)
+	}
+	if _, err := findTaskFile(3); err == nil {
+		t.Errorf("Expected task 3 to be removed")
+	}
+
+	output, _ = executeCommand("edit", "--new", "--template", "missing", "x")
+	if !strings.Contains(output, `no task template "missing"`) {
+		t.Errorf("Expected a missing template error, got %q", output)
+	}
+}
@@ -19,3 +19,12 @@ func printNotPunchlistError(err error) bool {
 	}
 	return false
 }
+
+// exitError asks Execute to exit with a status code without printing more
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
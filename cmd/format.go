@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"punchlist/task"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// output formats accepted by --format
+var outputFormats = []string{"text", "json", "ndjson", "yaml", "csv", "tsv"}
+
+// taskRecord is the stable machine-readable shape of a listed task
+type taskRecord struct {
+	ID           int      `json:"id" yaml:"id"`
+	Title        string   `json:"title" yaml:"title"`
+	State        string   `json:"state" yaml:"state"`
+	Priority     int      `json:"priority" yaml:"priority"`
+	Due          *string  `json:"due" yaml:"due"`
+	Tags         []string `json:"tags" yaml:"tags"`
+	CreatedAt    string   `json:"created_at" yaml:"created_at"`
+	UpdatedAt    string   `json:"updated_at" yaml:"updated_at"`
+	StartedAt    *string  `json:"started_at" yaml:"started_at"`
+	CompletedAt  *string  `json:"completed_at" yaml:"completed_at"`
+	ExternalRefs []string `json:"external_refs" yaml:"external_refs"`
+	Path         string   `json:"path" yaml:"path"`
+	// alias of the registered project, set when listing several projects
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+}
+
+// taskDetailRecord adds the body and history for pin show
+type taskDetailRecord struct {
+	taskRecord  `yaml:",inline"`
+	Transitions []transitionRecord `json:"transitions" yaml:"transitions"`
+	Body        string             `json:"body" yaml:"body"`
+}
+
+// transitionRecord is one state change in machine-readable form
+type transitionRecord struct {
+	At     string `json:"at" yaml:"at"`
+	From   string `json:"from" yaml:"from"`
+	To     string `json:"to" yaml:"to"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// csv and tsv column headers, matching taskRecord
+var recordColumns = []string{
+	"id", "title", "state", "priority", "due", "tags",
+	"created_at", "updated_at", "started_at", "completed_at",
+	"external_refs", "path",
+}
+
+// add the --format flag
+func addFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().String("format", "text", "Output format: "+strings.Join(outputFormats, ", "))
+}
+
+// read and validate the --format flag
+func formatFlag(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	format = strings.ToLower(strings.TrimSpace(format))
+	for _, known := range outputFormats {
+		if format == known {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q (use %s)", format, strings.Join(outputFormats, ", "))
+}
+
+// convert a task to its stable record
+func newTaskRecord(t *task.Task) taskRecord {
+	tags := t.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	refs := t.ExternalRefs
+	if refs == nil {
+		refs = []string{}
+	}
+	return taskRecord{
+		ID:           t.ID,
+		Title:        t.Title,
+		State:        string(t.State),
+		Priority:     t.Priority,
+		Due:          isoTimePtr(t.Due),
+		Tags:         tags,
+		CreatedAt:    isoTime(t.CreatedAt),
+		UpdatedAt:    isoTime(t.UpdatedAt),
+		StartedAt:    isoTimePtr(t.StartedAt),
+		CompletedAt:  isoTimePtr(t.CompletedAt),
+		ExternalRefs: refs,
+		Path:         t.Path(),
+	}
+}
+
+// convert a task to its detailed record
+func newTaskDetailRecord(t *task.Task) taskDetailRecord {
+	transitions := make([]transitionRecord, 0, len(t.Transitions))
+	for _, tr := range t.Transitions {
+		transitions = append(transitions, transitionRecord{
+			At:     isoTime(tr.At),
+			From:   string(tr.From),
+			To:     string(tr.To),
+			Reason: tr.Reason,
+		})
+	}
+	return taskDetailRecord{
+		taskRecord:  newTaskRecord(t),
+		Transitions: transitions,
+		Body:        t.Body,
+	}
+}
+
+// render a timestamp as rfc3339
+func isoTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+// render an optional timestamp as rfc3339 or nil
+func isoTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := isoTime(*t)
+	return &s
+}
+
+// write records for many tasks in a machine-readable format; columns limit
+// csv and tsv output, and projectOf labels tasks gathered from several projects
+func writeTaskList(w io.Writer, format string, tasks []*task.Task, columns []string, projectOf map[*task.Task]string) error {
+	records := make([]taskRecord, 0, len(tasks))
+	for _, t := range tasks {
+		record := newTaskRecord(t)
+		record.Project = projectOf[t]
+		records = append(records, record)
+	}
+	if projectOf != nil && (format == "csv" || format == "tsv") {
+		if len(columns) == 0 {
+			columns = recordColumns
+		}
+		columns = append([]string{"project"}, columns...)
+	}
+
+	switch format {
+	case "json":
+		return writeJSON(w, records)
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "yaml":
+		return writeYAML(w, records)
+	case "csv", "tsv":
+		return writeDelimited(w, format, records, columns)
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// write a single task with its body and history
+func writeTaskDetail(w io.Writer, format string, t *task.Task) error {
+	record := newTaskDetailRecord(t)
+	switch format {
+	case "json":
+		return writeJSON(w, record)
+	case "ndjson":
+		return json.NewEncoder(w).Encode(record)
+	case "yaml":
+		return writeYAML(w, record)
+	case "csv", "tsv":
+		return writeDelimited(w, format, []taskRecord{record.taskRecord}, nil)
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// write indented json
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// write yaml with the same indent as task files
+func writeYAML(w io.Writer, value any) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// write csv or tsv with a header row, limited to columns when given
+func writeDelimited(w io.Writer, format string, records []taskRecord, columns []string) error {
+	if len(columns) == 0 {
+		columns = recordColumns
+	}
+	writer := csv.NewWriter(w)
+	if format == "tsv" {
+		writer.Comma = '\t'
+	}
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := make([]string, 0, len(columns))
+		for _, column := range columns {
+			row = append(row, recordValue(r, column))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// one record field as csv text
+func recordValue(r taskRecord, column string) string {
+	switch column {
+	case "id":
+		return strconv.Itoa(r.ID)
+	case "title":
+		return r.Title
+	case "state":
+		return r.State
+	case "priority":
+		return strconv.Itoa(r.Priority)
+	case "due":
+		return derefString(r.Due)
+	case "tags":
+		return strings.Join(r.Tags, ",")
+	case "created_at":
+		return r.CreatedAt
+	case "updated_at":
+		return r.UpdatedAt
+	case "started_at":
+		return derefString(r.StartedAt)
+	case "completed_at":
+		return derefString(r.CompletedAt)
+	case "external_refs":
+		return strings.Join(r.ExternalRefs, ",")
+	case "path":
+		return r.Path
+	case "project":
+		return r.Project
+	}
+	return ""
+}
+
+// read an optional string as empty when unset
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// test machine-readable output from ls and show
+func TestFormatOutput(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Ship, the \"release\"", "pri:2", "by:2026-01-15", "tags:{launch,web}")
+	executeCommand("todo", "Plain task")
+	executeCommand("start", "2")
+
+	output, _ := executeCommand("ls", "--format", "json", "--order", "id")
+	var records []map[string]any
+	if err := json.Unmarshal([]byte(output), &records); err != nil {
+		t.Fatalf("ls json did not parse: %v\n%s", err, output)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	first := records[0]
+	if first["title"] != "Ship, the \"release\"" || first["priority"] != float64(2) || first["state"] != "TODO" {
+		t.Errorf("Unexpected first record: %v", first)
+	}
+	if due, _ := first["due"].(string); !strings.HasPrefix(due, "2026-01-15T") {
+		t.Errorf("Expected rfc3339 due date, got %v", first["due"])
+	}
+	if path, _ := first["path"].(string); !strings.HasSuffix(path, "001-ship-the-release.md") {
+		t.Errorf("Expected task path, got %v", first["path"])
+	}
+	second := records[1]
+	if second["due"] != nil || second["completed_at"] != nil {
+		t.Errorf("Expected null optional fields, got %v", second)
+	}
+	if tags, ok := second["tags"].([]any); !ok || len(tags) != 0 {
+		t.Errorf("Expected empty tag list, got %v", second["tags"])
+	}
+	if second["started_at"] == nil {
+		t.Errorf("Expected started_at for begun task")
+	}
+
+	output, _ = executeCommand("ls", "tag:missing", "--format", "json")
+	if strings.TrimSpace(output) != "[]" {
+		t.Errorf("Expected empty json array, got %q", output)
+	}
+
+	output, _ = executeCommand("ls", "--format", "ndjson", "--order", "id")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 ndjson lines, got %q", output)
+	}
+	for _, line := range lines {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Errorf("ndjson line did not parse: %v", err)
+		}
+	}
+
+	output, _ = executeCommand("ls", "--format", "csv", "--order", "id")
+	rows, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("ls csv did not parse: %v", err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != strings.Join(recordColumns, ",") {
+		t.Fatalf("Unexpected csv rows: %v", rows)
+	}
+	if rows[1][1] != "Ship, the \"release\"" || rows[1][5] != "launch,web" {
+		t.Errorf("Unexpected csv row: %v", rows[1])
+	}
+
+	output, _ = executeCommand("ls", "--format", "tsv")
+	if !strings.HasPrefix(output, "id\ttitle\tstate\t") {
+		t.Errorf("Expected tsv header, got %q", output)
+	}
+
+	output, _ = executeCommand("show", "2", "--format", "yaml")
+	var detail map[string]any
+	if err := yaml.Unmarshal([]byte(output), &detail); err != nil {
+		t.Fatalf("show yaml did not parse: %v\n%s", err, output)
+	}
+	if detail["id"] != 2 || !strings.Contains(detail["body"].(string), "# Plain task") {
+		t.Errorf("Unexpected show record: %v", detail)
+	}
+	transitions, _ := detail["transitions"].([]any)
+	if len(transitions) != 1 {
+		t.Errorf("Expected one transition, got %v", detail["transitions"])
+	}
+
+	output, _ = executeCommand("ls", "--format", "xml")
+	if !strings.Contains(output, `unknown format "xml"`) {
+		t.Errorf("Expected unknown format error, got %q", output)
+	}
+}
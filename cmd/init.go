@@ -26,6 +26,7 @@ func newInitCmd() *cobra.Command {
 			// avoid overwriting an existing init
 			if _, err := os.Stat(punchlistPath); !os.IsNotExist(err) {
 				fmt.Println("Punchlist project already initialized.")
+				registerProject(cwd)
 				return
 			}
 
@@ -57,12 +58,13 @@ func newInitCmd() *cobra.Command {
 				IDWidth:      config.DefaultIDWidth(),
 				LsStateOrder: config.DefaultLsStateOrder(),
 			}
-			if err := config.SaveConfig(defaultConfig); err != nil {
+			if err := config.SaveConfigTo(cwd, defaultConfig); err != nil {
 				fmt.Printf("Error creating default config: %v\n", err)
 				return
 			}
 
 			fmt.Println("Punchlist project initialized successfully.")
+			registerProject(cwd)
 		},
 	}
 	return cmd
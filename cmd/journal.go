@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/fsutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// folder inside .punchlist with one file per recorded operation
+const journalDirName = "journal"
+
+// command line of this run, used to label journal entries
+var commandLine string
+
+// one recorded operation: every task and trash file it changed, before and
+// after
+type journalEntry struct {
+	Seq     int       `json:"seq"`
+	At      time.Time `json:"at"`
+	Command string    `json:"command"`
+	// kept in the file name so recording needn't read old entries
+	Undone bool          `json:"-"`
+	Files  []journalFile `json:"files"`
+}
+
+// one file changed by an operation
+type journalFile struct {
+	// relative to the project root, with forward slashes
+	Path string `json:"path"`
+	// nil when the file didn't exist
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// the task and trash files one locked operation touches, with their contents
+// from before it first touched each
+type journalRecorder struct {
+	root   string
+	before map[string]*string
+	// the first file that couldn't be read, so the entry would be incomplete
+	err error
+}
+
+// label journal entries with the arguments pin was run with, quoting any
+// that need it
+func setCommandLine(args []string) {
+	words := []string{"pin"}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		words = append(words, arg)
+	}
+	commandLine = strings.Join(words, " ")
+}
+
+// folder holding the journal for a project root
+func journalDir(root string) string {
+	return filepath.Join(root, config.PunchlistDir, journalDirName)
+}
+
+// start recording an operation in root; originals holds contents, keyed by
+// absolute path, of files changed before the lock was taken, such as in an
+// editor, that the operation should start from
+func newJournalRecorder(root string, originals map[string][]byte) *journalRecorder {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	r := &journalRecorder{root: root, before: map[string]*string{}}
+	r.addOriginals(originals)
+	return r
+}
+
+// count files changed outside the lock as part of this operation
+func (r *journalRecorder) addOriginals(originals map[string][]byte) {
+	for path, data := range originals {
+		if rel, ok := r.journalPath(path); ok {
+			content := string(data)
+			r.before[rel] = &content
+		}
+	}
+}
+
+// the path relative to root of a task or trash file, or false for anything
+// else, such as config, the journal itself or temp files
+func (r *journalRecorder) journalPath(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(r.root, abs)
+	if err != nil || fsutil.IsTempFile(filepath.Base(rel)) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "tasks/") && !strings.HasPrefix(rel, ".trash/") {
+		return "", false
+	}
+	return rel, true
+}
+
+// note a file's contents before the operation first changes it
+func (r *journalRecorder) touch(path string) {
+	rel, ok := r.journalPath(path)
+	if !ok {
+		return
+	}
+	if _, seen := r.before[rel]; seen {
+		return
+	}
+	content, err := readJournalFile(path)
+	if err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		return
+	}
+	r.before[rel] = content
+}
+
+// the touched files whose contents differ now, sorted by path
+func (r *journalRecorder) files() ([]journalFile, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	files := []journalFile{}
+	for rel, before := range r.before {
+		after, err := readJournalFile(filepath.Join(r.root, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		if (before == nil && after == nil) || (before != nil && after != nil && *before == *after) {
+			continue
+		}
+		files = append(files, journalFile{Path: rel, Before: before, After: after})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// a file's contents, or nil when it doesn't exist
+func readJournalFile(path string) (*string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	return &content, nil
+}
+
+// record what an operation changed as the next journal entry, dropping any
+// undone operations it replaces and the oldest past journal_limit
+func recordOperation(root string, r *journalRecorder) error {
+	files, err := r.files()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	names, err := journalNames(root)
+	if err != nil {
+		return err
+	}
+	seq := 1
+	kept := []journalName{}
+	for _, name := range names {
+		seq = name.seq + 1
+		if name.undone {
+			if err := os.Remove(journalEntryPath(root, name.seq, true)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		kept = append(kept, name)
+	}
+
+	label := commandLine
+	if label == "" {
+		label = "pin"
+	}
+	entry := &journalEntry{Seq: seq, At: time.Now(), Command: label, Files: files}
+	if err := saveJournalEntry(root, entry); err != nil {
+		return err
+	}
+
+	limit := journalLimit(root)
+	for len(kept) >= limit {
+		if err := os.Remove(journalEntryPath(root, kept[0].seq, false)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		kept = kept[1:]
+	}
+	return nil
+}
+
+// how many operations to keep, from config or defaults
+func journalLimit(root string) int {
+	cfg, err := config.LoadConfigFrom(root)
+	if err != nil || cfg.JournalLimit <= 0 {
+		return config.DefaultJournalLimit()
+	}
+	return cfg.JournalLimit
+}
+
+// suffix of journal entries that have been undone
+const undoneSuffix = ".undone.json"
+
+// a journal entry as named on disk
+type journalName struct {
+	seq    int
+	undone bool
+	file   string
+}
+
+// path of one journal entry
+func journalEntryPath(root string, seq int, undone bool) string {
+	suffix := ".json"
+	if undone {
+		suffix = undoneSuffix
+	}
+	return filepath.Join(journalDir(root), fmt.Sprintf("%06d%s", seq, suffix))
+}
+
+// list journal entries by file name alone, oldest first
+func journalNames(root string) ([]journalName, error) {
+	dirEntries, err := os.ReadDir(journalDir(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []journalName{}
+	for _, dirEntry := range dirEntries {
+		file := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(file, ".json") {
+			continue
+		}
+		name := journalName{file: file, undone: strings.HasSuffix(file, undoneSuffix)}
+		seq := strings.TrimSuffix(strings.TrimSuffix(file, undoneSuffix), ".json")
+		if name.seq, err = strconv.Atoi(seq); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].seq < names[j].seq })
+	return names, nil
+}
+
+// load every journal entry, oldest first
+func loadJournal(root string) ([]*journalEntry, error) {
+	names, err := journalNames(root)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*journalEntry{}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(journalDir(root), name.file))
+		if err != nil {
+			return nil, err
+		}
+		entry := &journalEntry{}
+		if err := json.Unmarshal(data, entry); err != nil {
+			return nil, fmt.Errorf("invalid journal entry %s: %w", name.file, err)
+		}
+		entry.Seq, entry.Undone = name.seq, name.undone
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// write one journal entry, named for whether it's undone
+func saveJournalEntry(root string, entry *journalEntry) error {
+	if err := os.MkdirAll(journalDir(root), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(journalEntryPath(root, entry.Seq, entry.Undone), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if err := os.Remove(journalEntryPath(root, entry.Seq, !entry.Undone)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// check that every file an entry touched still has the contents it expects,
+// so changes made since, by hand or by unrecorded tools, aren't overwritten
+func checkJournalFiles(root string, files []journalFile, expected func(journalFile) *string) error {
+	for _, file := range files {
+		want := expected(file)
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file.Path)))
+		switch {
+		case err != nil && !os.IsNotExist(err):
+			return err
+		case err != nil && want != nil:
+			return fmt.Errorf("%s has been removed since", file.Path)
+		case err == nil && want == nil:
+			return fmt.Errorf("%s has been created since", file.Path)
+		case err == nil && string(data) != *want:
+			return fmt.Errorf("%s has been changed since", file.Path)
+		}
+	}
+	return nil
+}
+
+// put every file an entry touched back to one side of the change
+func applyJournalFiles(root string, files []journalFile, contents func(journalFile) *string) error {
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file.Path))
+		content := contents(file)
+		if content == nil {
+			if err := fsutil.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			// drop the trash records folder once its last record is gone;
+			// this fails harmlessly while others remain
+			if dir := filepath.Dir(path); filepath.Base(dir) == trashMetaDirName {
+				os.Remove(dir)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := fsutil.WriteFile(path, []byte(*content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// a journal file's contents before its operation
+func journalBefore(file journalFile) *string {
+	return file.Before
+}
+
+// a journal file's contents after its operation
+func journalAfter(file journalFile) *string {
+	return file.After
+}
+
+// describe the files an entry touched, naming the first few
+func journalFileSummary(files []journalFile) string {
+	names := []string{}
+	for i, file := range files {
+		if i == 3 {
+			names = append(names, fmt.Sprintf("%d more", len(files)-3))
+			break
+		}
+		names = append(names, file.Path)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/fsutil"
+	"time"
+)
+
+// name of the advisory lock file inside .punchlist
+const lockFileName = "lock"
+
+// what this process tracks for a project root while it holds the lock
+type heldLock struct {
+	// counted so nested calls don't deadlock
+	depth int
+	// nil when the operation isn't recorded, as for undo and redo
+	journal *journalRecorder
+	// task files parsed once for id lookups, with the paths changed since
+	index   *taskIndex
+	changed map[string]bool
+}
+
+// project roots locked by this process
+var heldLocks = map[string]*heldLock{}
+
+// run fn while holding the project lock so concurrent pin runs serialize,
+// recording what it changes in the journal for pin undo
+func withProjectLock(fn func() error) error {
+	return lockProject(fn, true, nil)
+}
+
+// run fn under the project lock, recording it as starting from originals,
+// the contents by absolute path of files changed before the lock was taken
+func withProjectLockFrom(originals map[string][]byte, fn func() error) error {
+	return lockProject(fn, true, originals)
+}
+
+// run fn under the project lock without recording it, for undo and redo
+func withProjectLockUnrecorded(fn func() error) error {
+	return lockProject(fn, false, nil)
+}
+
+// take the project lock unless this process already holds it, and record
+// the files fn writes, renames or removes as one journal entry when asked
+func lockProject(fn func() error, record bool, originals map[string][]byte) error {
+	root, err := punchlistRoot()
+	if err != nil {
+		return err
+	}
+	if held := heldLocks[root]; held != nil {
+		held.depth++
+		defer func() { held.depth-- }()
+		if held.journal != nil {
+			held.journal.addOriginals(originals)
+		}
+		return fn()
+	}
+
+	cfg, err := config.LoadConfigFrom(root)
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	lockPath := filepath.Join(root, config.PunchlistDir, lockFileName)
+	lock, err := fsutil.LockFile(lockPath, lockTimeoutFromConfig(cfg))
+	if err != nil {
+		if errors.Is(err, fsutil.ErrLockTimeout) {
+			return fmt.Errorf("another pin command is changing this punchlist (%v); try again, or raise lock_timeout in %s/config.yaml", err, config.PunchlistDir)
+		}
+		return err
+	}
+	held := &heldLock{depth: 1}
+	if record {
+		held.journal = newJournalRecorder(root, originals)
+	}
+	heldLocks[root] = held
+	fsutil.SetChangeHook(trackChange)
+	defer func() {
+		delete(heldLocks, root)
+		if len(heldLocks) == 0 {
+			fsutil.SetChangeHook(nil)
+		}
+		lock.Unlock()
+	}()
+
+	err = fn()
+	if held.journal != nil {
+		// a partly failed command is still recorded so what it did can be undone
+		if recordErr := recordOperation(root, held.journal); recordErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not record this change for pin undo: %v\n", recordErr)
+		}
+	}
+	return err
+}
+
+// pass a path about to change to every held lock's journal and task index;
+// each ignores paths outside its project
+func trackChange(path string) {
+	for _, held := range heldLocks {
+		if held.journal != nil {
+			held.journal.touch(path)
+		}
+		if held.index != nil {
+			held.changed[filepath.Clean(path)] = true
+		}
+	}
+}
+
+// choose the lock wait from config or defaults
+func lockTimeoutFromConfig(cfg *config.Config) time.Duration {
+	if cfg == nil || cfg.LockTimeout == "" {
+		return config.DefaultLockTimeout()
+	}
+	timeout, err := time.ParseDuration(cfg.LockTimeout)
+	if err != nil || timeout <= 0 {
+		return config.DefaultLockTimeout()
+	}
+	return timeout
+}
@@ -26,41 +26,49 @@ func newLogCmd() *cobra.Command {
 				return
 			}
 
-			taskPath, err := findTaskFile(id)
-			if err != nil {
+			if err := withProjectLock(func() error { return addTaskLog(id, message) }); err != nil {
 				if printNotPunchlistError(err) {
 					return
 				}
-				fmt.Printf("Error finding task: %v\n", err)
+				fmt.Printf("Error updating task: %v\n", err)
 				return
 			}
 
-			t, err := task.Parse(taskPath)
-			if err != nil {
-				fmt.Printf("Error parsing task: %v\n", err)
-				return
-			}
+			fmt.Printf("Added log to task %d\n", id)
+		},
+	}
+}
+
+// append a timestamped entry to a task's log section
+func addTaskLog(id int, message string) error {
+	taskPath, err := findTaskFile(id)
+	if err != nil {
+		return err
+	}
 
-			// add a timestamped entry
-			logEntry := fmt.Sprintf("- %s: %s", time.Now().Format(time.RFC3339), message)
+	t, err := task.Parse(taskPath)
+	if err != nil {
+		return fmt.Errorf("error parsing task: %w", err)
+	}
 
-			pre, logSection, afterLog, found := splitSection(t.Body, "## Log")
-			if found {
-				pre += afterLog
-			} else {
-				logSection = "## Log"
-			}
+	now := time.Now()
+	t.Body = appendLogEntry(t.Body, message, now)
+	t.UpdatedAt = now
 
-			logSection = appendEntry(logSection, logEntry)
-			t.Body = joinBlocks(pre, logSection)
-			t.UpdatedAt = time.Now()
+	return t.Write(taskPath)
+}
 
-			if err := t.Write(taskPath); err != nil {
-				fmt.Printf("Error updating task: %v\n", err)
-				return
-			}
+// add a timestamped entry to the log section of a body
+func appendLogEntry(body, message string, now time.Time) string {
+	logEntry := fmt.Sprintf("- %s: %s", now.Format(time.RFC3339), message)
 
-			fmt.Printf("Added log to task %d\n", id)
-		},
+	pre, logSection, afterLog, found := splitSection(body, "## Log")
+	if found {
+		pre += afterLog
+	} else {
+		logSection = "## Log"
 	}
+
+	logSection = appendEntry(logSection, logEntry)
+	return joinBlocks(pre, logSection)
 }
@@ -2,12 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"io/fs"
 	"os"
-	"path/filepath"
 	"punchlist/config"
+	"punchlist/filter"
 	"punchlist/task"
-	"sort"
 	"strings"
 	"time"
 
@@ -19,187 +17,305 @@ const stateSeparatorLine = "----------------------------------------"
 // create the ls command
 func newLsCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:               "ls [state]",
-		Short:             "List tasks",
-		Long:              `List tasks, optionally filtering by state, priority, and tags.`,
-		ValidArgsFunction: stateArgCompletion,
-		Run: func(cmd *cobra.Command, args []string) {
-			// read filter and sort flags
-			lsPriority, _ := cmd.Flags().GetInt("pri")
-			lsTags, _ := cmd.Flags().GetStringSlice("tag")
-			lsOrder, _ := cmd.Flags().GetString("order")
-			lsReverse, _ := cmd.Flags().GetBool("reverse")
-
-			targetPath, remainingArgs := extractTargetPath(args)
-
-			// scan tasks directory
-			var tasksPath string
-			var err error
-			if targetPath != "" {
-				root, err := punchlistRootFromPath(targetPath)
-				if err != nil {
-					if printNotPunchlistError(err) {
-						return
-					}
-					fmt.Printf("Error locating tasks: %v\n", err)
-					return
-				}
-				tasksPath = filepath.Join(root, "tasks")
-			} else {
-				tasksPath, err = tasksDir()
-				if err != nil {
-					if printNotPunchlistError(err) {
-						return
-					}
-					fmt.Printf("Error locating tasks: %v\n", err)
-					return
-				}
-			}
-			if _, err := os.Stat(tasksPath); os.IsNotExist(err) {
-				fmt.Println("No tasks found.")
-				return
-			}
+		Use:   "ls [path] [filter]",
+		Short: "List tasks",
+		Long: `List tasks, optionally filtering by state, priority, tags, or a filter expression.
 
-			// parse optional state filter
-			var filterState task.State
-			if len(remainingArgs) > 0 {
-				if parsed, ok := task.ParseState(remainingArgs[0]); ok {
-					filterState = parsed
-				} else {
-					filterState = task.State(remainingArgs[0])
-				}
-			}
+Filter expressions combine terms with and, or, not, and parentheses:
+  pin ls todo
+  pin ls 'state in (todo,begun) and pri<=2'
+  pin ls 'due<+7d and tag:launch and not tag:later'
+  pin ls 'title~"release" or missing:due'
 
-			// load tasks and apply filters
-			var tasks []*task.Task
-
-			err = filepath.WalkDir(tasksPath, func(path string, d fs.DirEntry, err error) error {
-				if err != nil {
-					return err
-				}
-				if !d.IsDir() && strings.HasSuffix(d.Name(), ".md") {
-					t, err := task.Parse(path)
-					if err != nil {
-						fmt.Printf("Error parsing task file %s: %v\n", path, err)
-						return nil // continue walking
-					}
-
-					if filterState != "" && t.State != filterState {
-						return nil
-					}
-					if lsPriority != 0 && t.Priority != lsPriority {
-						return nil
-					}
-
-					if len(lsTags) > 0 {
-						tagMatch := false
-						for _, tag := range lsTags {
-							for _, taskTag := range t.Tags {
-								if tag == taskTag {
-									tagMatch = true
-									break
-								}
-							}
-							if tagMatch {
-								break
-							}
-						}
-						if !tagMatch {
-							return nil
-						}
-					}
-
-					tasks = append(tasks, t)
-				}
-				return nil
-			})
+Sort by several keys, with - for descending; tasks without the value go last:
+  pin ls --sort due,-pri,id
 
-			if err != nil {
-				fmt.Printf("Error listing tasks: %v\n", err)
-				return
-			}
+Run a view saved in config, optionally narrowed further:
+  pin ls @today
+  pin ls @today tag:launch
 
-			// order results
-			sortTasks(tasks, lsOrder, lsReverse)
+List several registered projects at once, each task prefixed with its alias:
+  pin ls @work,@home
+  pin ls --all open
 
-			// print aligned ids
-			idWidth := maxIDWidth(tasks)
-			configWidth := loadIDWidth()
-			if configWidth > idWidth {
-				idWidth = configWidth
-			}
-			shouldGroupByState := filterState == "" &&
-				lsPriority == 0 &&
-				len(lsTags) == 0 &&
-				strings.ToLower(strings.TrimSpace(lsOrder)) != "id"
-			var lastState task.State
-			for _, t := range tasks {
-				if shouldGroupByState && lastState != "" && t.State != lastState {
-					fmt.Println(stateSeparatorLine)
-				}
-				tagSuffix := ""
-				if len(t.Tags) > 0 {
-					tagSuffix = fmt.Sprintf(" {%s}", strings.Join(t.Tags, ","))
-				}
-				fmt.Printf("%*d %s %s pri:%d due:%s%s\n",
-					idWidth,
-					t.ID,
-					t.State,
-					t.Title,
-					t.Priority,
-					formatDueDate(t.Due),
-					tagSuffix,
-				)
-				lastState = t.State
-			}
+List every punchlist in a folder tree, such as one per service in a monorepo:
+  pin ls --recursive
+  pin ls -R ./services open
+
+Render each task with a Go template, or a named one from .punchlist/templates:
+  pin ls --template '{{.ID}} {{.Title | trunc 40}} {{.Due | rel}}'
+  pin ls --template-name compact
+
+See docs/grammar.md for every field and operator, and docs/templates.md for
+template helpers.`,
+		ValidArgsFunction: lsArgCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			runList(cmd, args)
 		},
 	}
 
+	addListFlags(cmd)
+	return cmd
+}
+
+// add the filter, sort and output flags shared by ls and view
+func addListFlags(cmd *cobra.Command) {
 	cmd.Flags().Int("pri", 0, "Filter by priority")
 	cmd.Flags().StringSlice("tag", []string{}, "Filter by tag (can be used multiple times)")
 	cmd.Flags().String("order", "state", "Order by state or id")
+	cmd.Flags().String("sort", "", "Sort keys, e.g. due,-pri,id (- for descending)")
 	cmd.Flags().Bool("reverse", false, "Reverse sort order")
-
-	return cmd
+	addColumnsFlag(cmd)
+	cmd.Flags().Bool("all", false, "List tasks from every registered project")
+	cmd.Flags().BoolP("recursive", "R", false, "List tasks from every punchlist in the folder tree")
+	cmd.Flags().Bool("wrap", false, "Wrap long titles in tables instead of truncating them")
+	addFormatFlag(cmd)
+	addTemplateFlags(cmd)
 }
 
-// render due dates consistently
-func formatDueDate(t *time.Time) string {
-	if t == nil {
-		return "n/a"
+// list tasks for ls or view, resolving a leading path to its project, or
+// to every project beneath it with --recursive
+func runList(cmd *cobra.Command, args []string) {
+	targetPath, remainingArgs := extractTargetPath(args)
+
+	var err error
+	if recursive, _ := cmd.Flags().GetBool("recursive"); recursive {
+		start := targetPath
+		if start == "" {
+			start = "."
+		}
+		var projects []config.Project
+		if projects, err = punchlistRootsFromPath(start); err == nil {
+			err = listTasks(cmd, remainingArgs, projects)
+		}
+	} else if targetPath != "" {
+		root, rootErr := punchlistRootFromPath(targetPath)
+		if rootErr != nil {
+			if printNotPunchlistError(rootErr) {
+				return
+			}
+			fmt.Printf("Error locating tasks: %v\n", rootErr)
+			return
+		}
+		err = withRoot(root, func() error { return listTasks(cmd, remainingArgs, nil) })
+	} else {
+		err = listTasks(cmd, remainingArgs, nil)
+	}
+	if err != nil {
+		if printNotPunchlistError(err) {
+			return
+		}
+		fmt.Printf("Error listing tasks: %v\n", err)
 	}
-	return t.Format("2006-01-02")
 }
 
-// sort tasks by the requested ordering
-func sortTasks(tasks []*task.Task, order string, reverse bool) {
-	order = strings.ToLower(strings.TrimSpace(order))
-	switch order {
-	case "id":
-		sort.Slice(tasks, func(i, j int) bool {
-			if reverse {
-				return tasks[i].ID > tasks[j].ID
+// list tasks matching flags and a filter expression in the given projects,
+// or else in registered projects for --all or @a,@b, or the active project
+func listTasks(cmd *cobra.Command, filterArgs []string, projects []config.Project) error {
+	var err error
+	if projects == nil {
+		all, _ := cmd.Flags().GetBool("all")
+		if projects, filterArgs, err = projectsFromArgs(filterArgs, all); err != nil {
+			return err
+		}
+	}
+	filterArgs, err = applyViewArgs(cmd, filterArgs)
+	if err != nil {
+		return err
+	}
+
+	// read filter and sort flags
+	lsPriority, _ := cmd.Flags().GetInt("pri")
+	lsTags, _ := cmd.Flags().GetStringSlice("tag")
+	lsReverse, _ := cmd.Flags().GetBool("reverse")
+	format, err := formatFlag(cmd)
+	if err != nil {
+		return err
+	}
+	columns, err := columnsFlag(cmd)
+	if err != nil {
+		return err
+	}
+	tmpl, err := templateFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	sortKeys, err := sortKeysFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	expr := strings.TrimSpace(strings.Join(filterArgs, " "))
+	// collect matching tasks from the active project
+	collect := func() ([]*task.Task, error) {
+		taskFilter, err := filter.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		tasksPath, err := tasksDir()
+		if err != nil {
+			return nil, err
+		}
+		return selectTasks(tasksPath, func(t *task.Task) bool {
+			if !taskFilter.Match(t) {
+				return false
+			}
+			if lsPriority != 0 && t.Priority != lsPriority {
+				return false
 			}
-			return tasks[i].ID < tasks[j].ID
+			return len(lsTags) == 0 || hasAnyTag(t, lsTags)
 		})
-	default:
-		stateOrder := loadStateOrder()
-		orderIndex := buildStateOrderIndex(stateOrder)
-		sort.Slice(tasks, func(i, j int) bool {
-			ai := orderIndex[stateOrderKey(tasks[i].State)]
-			aj := orderIndex[stateOrderKey(tasks[j].State)]
-			if ai == aj {
-				if reverse {
-					return tasks[i].ID > tasks[j].ID
-				}
-				return tasks[i].ID < tasks[j].ID
+	}
+
+	var tasks []*task.Task
+	var projectOf map[*task.Task]string
+	if projects == nil {
+		tasksPath, err := tasksDir()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(tasksPath); os.IsNotExist(err) {
+			if format != "text" {
+				return writeTaskList(os.Stdout, format, nil, columns, nil)
+			}
+			fmt.Println("No tasks found.")
+			return nil
+		}
+		if tasks, err = collect(); err != nil {
+			return err
+		}
+	} else {
+		if err := checkFilterSyntax(expr); err != nil {
+			return err
+		}
+		projectOf = map[*task.Task]string{}
+		err := forEachProject(projects, func(p config.Project) error {
+			projectTasks, err := collect()
+			if err != nil {
+				return err
 			}
-			if reverse {
-				return ai > aj
+			for _, t := range projectTasks {
+				projectOf[t] = p.Alias
 			}
-			return ai < aj
+			tasks = append(tasks, projectTasks...)
+			return nil
 		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// order results
+	sortTasks(tasks, sortKeys, lsReverse)
+	if tmpl != nil {
+		return writeTemplate(os.Stdout, tmpl, tasks, projectOf)
+	}
+	if format != "text" {
+		return writeTaskList(os.Stdout, format, tasks, columns, projectOf)
+	}
+
+	shouldGroupByState := expr == "" &&
+		lsPriority == 0 &&
+		len(lsTags) == 0 &&
+		sortKeys[0].field == "state"
+
+	// a terminal gets a table fitted to its width; pipes keep one line per task
+	terminal := stdoutIsTerminal()
+	if len(columns) > 0 || terminal {
+		opts := tableOptions{color: colorEnabled(), groupByState: shouldGroupByState, projectOf: projectOf}
+		opts.wrap, _ = cmd.Flags().GetBool("wrap")
+		if terminal {
+			opts.width = terminalWidth()
+		}
+		if len(columns) == 0 {
+			columns = defaultTableColumns
+		}
+		if projectOf != nil {
+			columns = append([]string{"project"}, columns...)
+		}
+		return renderTable(os.Stdout, tasks, columns, opts)
+	}
+	printTaskLines(tasks, shouldGroupByState, colorEnabled(), projectOf)
+	return nil
+}
+
+// print one line per task with aligned ids, for pipes and scripts
+func printTaskLines(tasks []*task.Task, groupByState bool, color bool, projectOf map[*task.Task]string) {
+	idWidth := maxIDWidth(tasks)
+	configWidth := loadIDWidth()
+	if configWidth > idWidth {
+		idWidth = configWidth
+	}
+	projectWidth := 0
+	for _, alias := range projectOf {
+		if len(alias) > projectWidth {
+			projectWidth = len(alias)
+		}
+	}
+	now := time.Now()
+	var lastState task.State
+	for _, t := range tasks {
+		if groupByState && lastState != "" && t.State != lastState {
+			fmt.Println(paint(stateSeparatorLine, color, "dim"))
+		}
+		tagSuffix := ""
+		if len(t.Tags) > 0 {
+			tagSuffix = fmt.Sprintf(" {%s}", strings.Join(t.Tags, ","))
+		}
+		if projectOf != nil {
+			fmt.Printf("%-*s ", projectWidth, projectOf[t])
+		}
+		fmt.Printf("%*d %s %s %s %s%s\n",
+			idWidth,
+			t.ID,
+			paint(string(t.State), color, cellStyles(t, "state", now)...),
+			paint(t.Title, color, cellStyles(t, "title", now)...),
+			paint(fmt.Sprintf("pri:%d", t.Priority), color, cellStyles(t, "priority", now)...),
+			paint("due:"+formatDueDate(t.Due), color, cellStyles(t, "due", now)...),
+			tagSuffix,
+		)
+		lastState = t.State
+	}
+}
+
+// load tasks that satisfy keep, reporting files that fail to parse
+func selectTasks(tasksPath string, keep func(t *task.Task) bool) ([]*task.Task, error) {
+	entries, err := loadTaskFiles(tasksPath)
+	if err != nil {
+		return nil, err
 	}
+	tasks := []*task.Task{}
+	for _, entry := range entries {
+		if entry.parseErr != nil {
+			// stderr keeps machine-readable output on stdout intact
+			fmt.Fprintf(os.Stderr, "Error parsing task file %s: %v\n", entry.path, entry.parseErr)
+			continue
+		}
+		if keep == nil || keep(entry.task) {
+			tasks = append(tasks, entry.task)
+		}
+	}
+	return tasks, nil
+}
+
+// report whether a task carries any of the given tags
+func hasAnyTag(t *task.Task, tags []string) bool {
+	for _, tag := range tags {
+		for _, taskTag := range t.Tags {
+			if tag == taskTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// render due dates consistently
+func formatDueDate(t *time.Time) string {
+	if t == nil {
+		return "n/a"
+	}
+	return t.Format("2006-01-02")
 }
 
 // read state ordering from config
@@ -229,14 +345,7 @@ func buildStateOrderIndex(order []string) map[string]int {
 		}
 	}
 	defaultIndex := len(order)
-	for _, state := range []task.State{
-		task.StateBegun,
-		task.StateBlock,
-		task.StateTodo,
-		task.StateConfirm,
-		task.StateDone,
-		task.StateNotDo,
-	} {
+	for _, state := range task.ActiveWorkflow().Names() {
 		key := stateOrderKey(state)
 		if _, ok := index[key]; !ok {
 			index[key] = defaultIndex
@@ -265,6 +374,9 @@ func stateOrderKey(state task.State) string {
 
 // normalize state labels from config for ordering
 func normalizeOrderLabel(label string) (string, bool) {
+	if state, ok := task.ParseState(label); ok {
+		return stateOrderKey(state), true
+	}
 	switch strings.ToUpper(strings.TrimSpace(label)) {
 	case "TODO":
 		return stateOrderKey(task.StateTodo), true
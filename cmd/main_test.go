@@ -14,6 +14,10 @@ import (
 // setupTest creates a temporary directory for a test, changes into it, and returns a teardown function.
 func setupTest(t *testing.T) func() {
 	t.Helper()
+	// keep a developer's shell hook from redirecting tests to a real project
+	t.Setenv(config.RootEnvVar, "")
+	// keep pin init from registering test projects in the real registry
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
 	// correctly refer to the sandbox dir in the project root
 	sandboxDir, err := filepath.Abs("../sandbox")
 	if err != nil {
@@ -63,11 +67,12 @@ func executeCommand(args ...string) (string, error) {
 // executeWithArgs runs cobra using provided args
 func executeWithArgs(args []string) error {
 	root := NewRootCmd()
+	setCommandLine(args)
 	if len(args) > 0 && !strings.HasPrefix(args[0], "-") && !isSubcommand(root, args[0]) {
 		return createTaskFromArgs(args)
 	}
 	root.SetArgs(args)
-	return root.Execute()
+	return executeRootCmd(root)
 }
 
 // test init command behavior
@@ -301,6 +306,21 @@ func TestStateAndLsCmds(t *testing.T) {
 	if !strings.Contains(output, "Task 4") {
 		t.Errorf("ls block should contain Task 4. Got: %s", output)
 	}
+
+	// test ls with a filter expression
+	output, err = executeCommand("ls", "state in (todo,block) and pri>=1 and not tag:urgent")
+	if err != nil {
+		t.Fatalf("ls with expression failed: %v", err)
+	}
+	if !strings.Contains(output, "Task 3") || !strings.Contains(output, "Task 4") {
+		t.Errorf("ls expression should contain Task 3 and Task 4. Got: %s", output)
+	}
+	if strings.Contains(output, "Task 1") || strings.Contains(output, "Task 2") {
+		t.Errorf("ls expression should not contain Task 1 or Task 2. Got: %s", output)
+	}
+	if strings.Contains(output, stateSeparatorLine) {
+		t.Errorf("filtered ls should not group by state. Got: %s", output)
+	}
 }
 
 // test log command behavior
@@ -402,3 +422,169 @@ func TestLsPathTarget(t *testing.T) {
 		t.Errorf("ls with path should include task from target dir. Got: %s", output)
 	}
 }
+
+// test that commands find the project from a subdirectory
+func TestLsFromSubdirectory(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Nested lookup task")
+
+	if err := withWorkingDir("tasks", func() error {
+		output, err := executeCommand("ls")
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, "Nested lookup task") {
+			t.Errorf("ls from a subdirectory should find the parent project. Got: %s", output)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("ls from subdirectory failed: %v", err)
+	}
+}
+
+// test that --root picks the project for one run without carrying over to
+// the next
+func TestRootFlagAppliesToOneRun(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	initProjectIn(t, "work", "Ship release")
+	initProjectIn(t, "home", "Fix sink")
+
+	if err := withWorkingDir("home", func() error {
+		output, err := executeCommand("ls", "--root", "../work")
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, "Ship release") || strings.Contains(output, "Fix sink") {
+			t.Errorf("ls --root should list the other project. Got: %s", output)
+		}
+
+		output, err = executeCommand("ls")
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, "Fix sink") || strings.Contains(output, "Ship release") {
+			t.Errorf("ls after --root should list the current project. Got: %s", output)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("ls failed: %v", err)
+	}
+	if override := config.RootOverride(); override != "" {
+		t.Errorf("Expected no root override after the run, got %q", override)
+	}
+}
+
+// test that a path target wins over the project found from the working dir
+func TestPathTargetFromInsideProject(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	initProjectIn(t, "work")
+	initProjectIn(t, "home", "Fix sink")
+	workDir, err := filepath.Abs("work")
+	if err != nil {
+		t.Fatalf("Failed to get work dir: %v", err)
+	}
+
+	if err := withWorkingDir("home", func() error {
+		output, err := executeCommand("todo", workDir, "Path task")
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, "Created task 1:") {
+			t.Errorf("Expected the task created in the target project, got: %s", output)
+		}
+
+		output, err = executeCommand("ls", workDir)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(output, "Path task") || strings.Contains(output, "Fix sink") {
+			t.Errorf("ls with a path should list the target project. Got: %s", output)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("path target failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "tasks", "001-path-task.md")); err != nil {
+		t.Errorf("Expected the task file in the target project: %v", err)
+	}
+}
+
+// test that id allocation skips ids already on disk
+func TestCreateSkipsExistingIDs(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "First task")
+	executeCommand("todo", "Second task")
+
+	// simulate next_id falling behind after a merge
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.NextID = 1
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	output, err := executeCommand("todo", "Third task")
+	if err != nil {
+		t.Fatalf("pin command failed: %v", err)
+	}
+	if !strings.Contains(output, "Created task 3:") {
+		t.Errorf("Expected task 3 to be created, got: %s", output)
+	}
+	if _, err := os.Stat(filepath.Join("tasks", "001-first-task.md")); err != nil {
+		t.Errorf("Expected first task to be left intact: %v", err)
+	}
+
+	cfg, err = config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.NextID != 4 {
+		t.Errorf("Expected NextID to be 4, but got %d", cfg.NextID)
+	}
+}
+
+// test that state changes are recorded in frontmatter and the log
+func TestStateTransitionHistory(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Reopened task")
+	executeCommand("done", "1")
+	executeCommand("move", "1", "todo")
+	executeCommand("done", "1", "--reason", "fixed for real")
+
+	taskPath := filepath.Join("tasks", "001-reopened-task.md")
+	parsed, err := task.Parse(taskPath)
+	if err != nil {
+		t.Fatalf("Failed to parse task: %v", err)
+	}
+	if len(parsed.Transitions) != 3 {
+		t.Fatalf("Expected 3 transitions, got %d: %+v", len(parsed.Transitions), parsed.Transitions)
+	}
+	last := parsed.Transitions[2]
+	if last.From != task.StateTodo || last.To != task.StateDone || last.Reason != "fixed for real" {
+		t.Errorf("Unexpected last transition: %+v", last)
+	}
+
+	content, err := os.ReadFile(taskPath)
+	if err != nil {
+		t.Fatalf("Failed to read task file: %v", err)
+	}
+	if !strings.Contains(string(content), "state changed from DONE to TODO") ||
+		!strings.Contains(string(content), "state changed from TODO to DONE: fixed for real") {
+		t.Errorf("Expected transitions in the log section. File content:\n%s", content)
+	}
+}
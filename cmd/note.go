@@ -26,49 +26,52 @@ func newNoteCmd() *cobra.Command {
 				return
 			}
 
-			taskPath, err := findTaskFile(id)
-			if err != nil {
+			if err := withProjectLock(func() error { return addTaskNote(id, message) }); err != nil {
 				if printNotPunchlistError(err) {
 					return
 				}
-				fmt.Printf("Error finding task: %v\n", err)
+				fmt.Printf("Error updating task: %v\n", err)
 				return
 			}
 
-			t, err := task.Parse(taskPath)
-			if err != nil {
-				fmt.Printf("Error parsing task: %v\n", err)
-				return
-			}
+			fmt.Printf("Added note to task %d\n", id)
+		},
+	}
+}
 
-			// add a timestamped entry
-			noteEntry := fmt.Sprintf("- %s: %s", time.Now().Format(time.RFC3339), message)
+// append a timestamped entry to a task's notes section
+func addTaskNote(id int, message string) error {
+	taskPath, err := findTaskFile(id)
+	if err != nil {
+		return err
+	}
 
-			pre, logSection, afterLog, logFound := splitSection(t.Body, "## Log")
-			if logFound {
-				pre += afterLog
-			}
+	t, err := task.Parse(taskPath)
+	if err != nil {
+		return fmt.Errorf("error parsing task: %w", err)
+	}
 
-			beforeNotes, notesSection, afterNotes, notesFound := splitSection(pre, "## Notes")
-			if !notesFound {
-				notesSection = "## Notes"
-			}
+	// add a timestamped entry
+	noteEntry := fmt.Sprintf("- %s: %s", time.Now().Format(time.RFC3339), message)
 
-			notesSection = appendEntry(notesSection, noteEntry)
-			pre = joinBlocks(beforeNotes, notesSection, afterNotes)
-			if logFound {
-				t.Body = joinBlocks(pre, logSection)
-			} else {
-				t.Body = pre
-			}
-			t.UpdatedAt = time.Now()
+	pre, logSection, afterLog, logFound := splitSection(t.Body, "## Log")
+	if logFound {
+		pre += afterLog
+	}
 
-			if err := t.Write(taskPath); err != nil {
-				fmt.Printf("Error updating task: %v\n", err)
-				return
-			}
+	beforeNotes, notesSection, afterNotes, notesFound := splitSection(pre, "## Notes")
+	if !notesFound {
+		notesSection = "## Notes"
+	}
 
-			fmt.Printf("Added note to task %d\n", id)
-		},
+	notesSection = appendEntry(notesSection, noteEntry)
+	pre = joinBlocks(beforeNotes, notesSection, afterNotes)
+	if logFound {
+		t.Body = joinBlocks(pre, logSection)
+	} else {
+		t.Body = pre
 	}
+	t.UpdatedAt = time.Now()
+
+	return t.Write(taskPath)
 }
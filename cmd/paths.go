@@ -30,6 +30,14 @@ func trashDir() (string, error) {
 	return filepath.Join(root, ".trash"), nil
 }
 
+func templatesDir() (string, error) {
+	root, err := punchlistRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, config.PunchlistDir, "templates"), nil
+}
+
 func isPathToken(token string) bool {
 	return strings.HasPrefix(token, ".") || strings.HasPrefix(token, "/")
 }
@@ -49,21 +57,44 @@ func extractTargetPath(args []string) (string, []string) {
 }
 
 func punchlistRootFromPath(path string) (string, error) {
-	absPath, err := filepath.Abs(path)
+	return config.ValidateRoot(path)
+}
+
+// find every punchlist root in the tree under path, each labelled with its
+// location relative to path
+func punchlistRootsFromPath(path string) ([]config.Project, error) {
+	start, err := filepath.Abs(path)
 	if err != nil {
-		return "", fmt.Errorf("could not resolve path: %w", err)
+		return nil, fmt.Errorf("could not resolve path: %w", err)
 	}
-	info, err := os.Stat(filepath.Join(absPath, config.PunchlistDir))
+	roots, err := config.FindRoots(start)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return "", config.ErrPunchlistNotFound
-		}
-		return "", fmt.Errorf("could not access %s: %w", config.PunchlistDir, err)
+		return nil, err
 	}
-	if !info.IsDir() {
-		return "", fmt.Errorf("%s exists but is not a directory at %s", config.PunchlistDir, absPath)
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no punchlist projects found under %s", path)
+	}
+	projects := make([]config.Project, 0, len(roots))
+	for _, root := range roots {
+		rel, err := filepath.Rel(start, root)
+		if err != nil {
+			rel = root
+		}
+		projects = append(projects, config.Project{Alias: filepath.ToSlash(rel), Path: root})
 	}
-	return absPath, nil
+	return projects, nil
+}
+
+// run fn with root resolution pinned to an explicit punchlist root
+func withRoot(root string, fn func() error) error {
+	previous := config.RootOverride()
+	config.SetRootOverride(root)
+	applyProjectWorkflow()
+	defer func() {
+		config.SetRootOverride(previous)
+		applyProjectWorkflow()
+	}()
+	return fn()
 }
 
 func withWorkingDir(dir string, fn func() error) error {
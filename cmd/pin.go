@@ -21,6 +21,8 @@ type createOptions struct {
 
 // create a task from free-form args
 func createTaskFromArgs(args []string) error {
+	// custom states from config may lead the args
+	applyProjectWorkflow()
 	targetPath, remaining := extractTargetPath(args)
 	if len(remaining) == 0 {
 		return fmt.Errorf("missing title")
@@ -32,7 +34,7 @@ func createTaskFromArgs(args []string) error {
 	if err != nil {
 		return err
 	}
-	return withWorkingDir(root, func() error {
+	return withRoot(root, func() error {
 		return createTaskFromArgsInDir(remaining)
 	})
 }
@@ -67,26 +69,23 @@ func createTaskFromArgsInDir(args []string) error {
 		return err
 	}
 
-	// load config and increment id
+	// allocate the id and write the task under the project lock
+	return withProjectLock(func() error {
+		return writeNewTask(title, state, opts)
+	})
+}
+
+// allocate an id, write the task file, and advance next_id
+func writeNewTask(title string, state task.State, opts createOptions) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("error loading config: %w", err)
 	}
 
-	id := cfg.NextID
-	// build file path
-	slug := slugify(title)
-	idWidth := idWidthFromConfig(cfg)
-	filename := fmt.Sprintf("%0*d-%s.md", idWidth, id, slug)
-
-	tasksPath, err := tasksDir()
+	id, filePath, err := newTaskPath(cfg, title)
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(tasksPath, 0755); err != nil {
-		return fmt.Errorf("error creating tasks directory: %w", err)
-	}
-	filePath := filepath.Join(tasksPath, filename)
 
 	// assemble the task object
 	newTask := &task.Task{
@@ -108,8 +107,8 @@ func createTaskFromArgsInDir(args []string) error {
 
 	fmt.Printf("Created task %d: %s\n", id, filePath)
 
-	// increment and save the next id
-	cfg.NextID++
+	// advance the next id past the one just used
+	cfg.NextID = id + 1
 	if err := config.SaveConfig(cfg); err != nil {
 		return fmt.Errorf("error saving config: %w", err)
 	}
@@ -117,6 +116,41 @@ func createTaskFromArgsInDir(args []string) error {
 	return nil
 }
 
+// allocate an id for a new task and build its file path from the title
+func newTaskPath(cfg *config.Config, title string) (int, string, error) {
+	tasksPath, err := tasksDir()
+	if err != nil {
+		return 0, "", err
+	}
+	if err := os.MkdirAll(tasksPath, 0755); err != nil {
+		return 0, "", fmt.Errorf("error creating tasks directory: %w", err)
+	}
+
+	id, err := allocateTaskID(cfg, tasksPath)
+	if err != nil {
+		return 0, "", err
+	}
+	filename := fmt.Sprintf("%0*d-%s.md", idWidthFromConfig(cfg), id, slugify(title))
+	return id, filepath.Join(tasksPath, filename), nil
+}
+
+// pick the first id at or after next_id that no task file already uses
+func allocateTaskID(cfg *config.Config, tasksPath string) (int, error) {
+	idx, err := loadTaskIndex(tasksPath)
+	if err != nil {
+		return 0, err
+	}
+	used := idx.usedIDs()
+	id := cfg.NextID
+	if id < 1 {
+		id = 1
+	}
+	for used[id] {
+		id++
+	}
+	return id, nil
+}
+
 // choose a safe id width from config or defaults
 func idWidthFromConfig(cfg *config.Config) int {
 	if cfg == nil || cfg.IDWidth <= 0 {
@@ -169,7 +203,21 @@ func splitTitleAndModifiers(args []string) (string, []string, error) {
 		return "", nil, fmt.Errorf("missing title")
 	}
 
-	var titleParts []string
+	titleParts, mods, err := splitModifiers(args)
+	if err != nil {
+		return "", nil, err
+	}
+	title := strings.TrimSpace(strings.Join(titleParts, " "))
+	if title == "" {
+		return "", nil, fmt.Errorf("missing title")
+	}
+	return title, mods, nil
+}
+
+// split args into plain words and key:value modifiers, where a bare key
+// takes the words after it as its value
+func splitModifiers(args []string) ([]string, []string, error) {
+	var words []string
 	var mods []string
 
 	for i := 0; i < len(args); i++ {
@@ -181,7 +229,7 @@ func splitTitleAndModifiers(args []string) (string, []string, error) {
 			}
 
 			if i+1 >= len(args) {
-				return "", nil, fmt.Errorf("missing value for %s", key)
+				return nil, nil, fmt.Errorf("missing value for %s", key)
 			}
 
 			j := i + 1
@@ -200,21 +248,16 @@ func splitTitleAndModifiers(args []string) (string, []string, error) {
 			}
 
 			if len(valueParts) == 0 {
-				return "", nil, fmt.Errorf("missing value for %s", key)
+				return nil, nil, fmt.Errorf("missing value for %s", key)
 			}
 			mods = append(mods, fmt.Sprintf("%s:%s", key, strings.Join(valueParts, " ")))
 			i = j - 1
 			continue
 		}
 
-		titleParts = append(titleParts, args[i])
-	}
-
-	title := strings.TrimSpace(strings.Join(titleParts, " "))
-	if title == "" {
-		return "", nil, fmt.Errorf("missing title")
+		words = append(words, args[i])
 	}
-	return title, mods, nil
+	return words, mods, nil
 }
 
 // parse modifier tokens in key:value or key value form
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"punchlist/task"
+	"time"
+)
+
+// set a task's priority, 0 for none, and log the change
+func setTaskPriority(id int, priority int) error {
+	if priority < 0 {
+		return fmt.Errorf("priority must be 0 or more, got %d", priority)
+	}
+	taskPath, err := findTaskFile(id)
+	if err != nil {
+		return err
+	}
+
+	t, err := task.Parse(taskPath)
+	if err != nil {
+		return fmt.Errorf("error parsing task: %w", err)
+	}
+	if t.Priority == priority {
+		return nil
+	}
+
+	now := time.Now()
+	msg := priorityLogMessage(t.Priority, priority)
+	t.Priority = priority
+	t.UpdatedAt = now
+	t.Body = appendLogEntry(t.Body, msg, now)
+
+	return t.Write(taskPath)
+}
+
+// describe a priority change for the task log
+func priorityLogMessage(from, to int) string {
+	switch {
+	case to == 0:
+		return fmt.Sprintf("priority cleared (was %d)", from)
+	case from == 0:
+		return fmt.Sprintf("priority set to %d", to)
+	default:
+		return fmt.Sprintf("priority changed from %d to %d", from, to)
+	}
+}
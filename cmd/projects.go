@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/filter"
+	"punchlist/task"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// create the projects command and its subcommands
+func newProjectsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "projects",
+		Short: "List and manage registered punchlist projects",
+		Long: `List the punchlist projects registered in ~/.config/punchlist/projects.yaml.
+pin init registers a project; use the alias to list several at once:
+  pin ls @work,@home
+  pin ls --all
+  pin agenda --all`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := listProjects(); err != nil {
+				fmt.Printf("Error listing projects: %v\n", err)
+			}
+		},
+	}
+
+	add := &cobra.Command{
+		Use:   "add [path]",
+		Short: "Register a punchlist project",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := "."
+			if len(args) > 0 {
+				path = args[0]
+			}
+			alias, _ := cmd.Flags().GetString("alias")
+			if err := addProject(path, alias); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error registering project: %v\n", err)
+			}
+		},
+	}
+	add.Flags().String("alias", "", "Alias for the project (defaults to the folder name)")
+
+	alias := &cobra.Command{
+		Use:               "alias [alias|path] [new-alias]",
+		Short:             "Rename a registered project",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: projectArgCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.UpdateRegistry(func(reg *config.Registry) error {
+				p, err := reg.SetAlias(args[0], args[1])
+				if err == nil {
+					fmt.Printf("Project %s is now @%s\n", p.Path, p.Alias)
+				}
+				return err
+			})
+			if err != nil {
+				fmt.Printf("Error renaming project: %v\n", err)
+			}
+		},
+	}
+
+	remove := &cobra.Command{
+		Use:               "rm [alias|path]",
+		Short:             "Forget a registered project without touching its files",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: projectArgCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := config.UpdateRegistry(func(reg *config.Registry) error {
+				p, err := reg.Remove(args[0])
+				if err == nil {
+					fmt.Printf("Removed @%s (%s)\n", p.Alias, p.Path)
+				}
+				return err
+			})
+			if err != nil {
+				fmt.Printf("Error removing project: %v\n", err)
+			}
+		},
+	}
+
+	cmd.AddCommand(add, alias, remove)
+	return cmd
+}
+
+// print each project's alias, path and open task count
+func listProjects() error {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return err
+	}
+	if len(reg.Projects) == 0 {
+		fmt.Println("No projects registered. Run pin init or pin projects add in a project.")
+		return nil
+	}
+	width := 0
+	for _, p := range reg.Projects {
+		if len(p.Alias) > width {
+			width = len(p.Alias)
+		}
+	}
+	for _, p := range reg.Projects {
+		status := "missing"
+		if root, err := config.ValidateRoot(p.Path); err == nil {
+			open, err := countOpenTasks(root)
+			if err != nil {
+				status = err.Error()
+			} else {
+				status = fmt.Sprintf("%d open", open)
+			}
+		}
+		fmt.Printf("@%-*s  %s  (%s)\n", width, p.Alias, p.Path, status)
+	}
+	return nil
+}
+
+// count tasks in a project that aren't in a closed state
+func countOpenTasks(root string) (int, error) {
+	count := 0
+	err := withRoot(root, func() error {
+		entries, err := loadTaskFiles(filepath.Join(root, "tasks"))
+		if err != nil {
+			return err
+		}
+		workflow := task.ActiveWorkflow()
+		for _, entry := range entries {
+			if entry.parseErr == nil && !workflow.IsClosed(entry.task.State) {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// register the project at path, optionally under a chosen alias
+func addProject(path, alias string) error {
+	root, err := punchlistRootFromPath(path)
+	if err != nil {
+		return err
+	}
+	return config.UpdateRegistry(func(reg *config.Registry) error {
+		p, added := reg.Add(root)
+		if alias != "" && alias != p.Alias {
+			renamed, err := reg.SetAlias(p.Alias, alias)
+			if err != nil {
+				if added {
+					reg.Remove(root)
+				}
+				return err
+			}
+			p = renamed
+		}
+		if added || alias != "" {
+			fmt.Printf("Registered %s as @%s\n", p.Path, p.Alias)
+		} else {
+			fmt.Printf("%s is already registered as @%s\n", p.Path, p.Alias)
+		}
+		return nil
+	})
+}
+
+// register a freshly initialized project, warning rather than failing
+func registerProject(root string) {
+	err := config.UpdateRegistry(func(reg *config.Registry) error {
+		if p, added := reg.Add(root); added {
+			fmt.Printf("Registered as @%s (see pin projects).\n", p.Alias)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not register project: %v\n", err)
+	}
+}
+
+// projects named by an @a,@b argument, or every project for --all; nil
+// means the current project only. the argument is consumed when used.
+func projectsFromArgs(args []string, all bool) ([]config.Project, []string, error) {
+	if all {
+		reg, err := config.LoadRegistry()
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(reg.Projects) == 0 {
+			return nil, nil, fmt.Errorf("no projects registered; run pin init or pin projects add in a project")
+		}
+		return reg.Projects, args, nil
+	}
+	if len(args) == 0 || !strings.HasPrefix(args[0], viewPrefix) {
+		return nil, args, nil
+	}
+
+	names := strings.Split(args[0], ",")
+	reg, err := config.LoadRegistry()
+	if name := strings.TrimPrefix(names[0], viewPrefix); len(names) == 1 && isViewName(name) {
+		if err != nil {
+			return nil, args, nil
+		}
+		// @name means either, so refuse to guess
+		if _, ok := reg.Find(name); ok {
+			return nil, nil, fmt.Errorf("@%s is both a saved view and a registered project; rename one with pin projects alias %s <new-alias> or by editing views in %s/config.yaml", name, name, config.PunchlistDir)
+		}
+		return nil, args, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	projects := []config.Project{}
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.TrimSpace(name), viewPrefix)
+		if name == "" {
+			continue
+		}
+		p, ok := reg.Find(name)
+		if !ok {
+			if len(names) == 1 {
+				// not a project either; let the view lookup explain
+				return nil, args, nil
+			}
+			return nil, nil, fmt.Errorf("unknown project @%s (see pin projects)", name)
+		}
+		projects = append(projects, p)
+	}
+	return projects, args[1:], nil
+}
+
+// report whether the current project defines a view with this name
+func isViewName(name string) bool {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return false
+	}
+	_, ok := cfg.Views[name]
+	return ok
+}
+
+// run fn inside each project, warning about and skipping ones that fail; a
+// project whose workflow rejects a filter's states is left out quietly, and
+// an error is returned only when no project could run fn
+func forEachProject(projects []config.Project, fn func(p config.Project) error) error {
+	ran := 0
+	var lastErr, filterErr error
+	for _, p := range projects {
+		root, err := config.ValidateRoot(p.Path)
+		if err == nil {
+			err = withRoot(root, func() error { return fn(p) })
+		}
+		switch {
+		case err == nil:
+			ran++
+		case errors.Is(err, filter.ErrUnknownState):
+			filterErr = err
+		default:
+			fmt.Fprintf(os.Stderr, "Skipping @%s: %v\n", p.Alias, err)
+			lastErr = err
+		}
+	}
+	if ran > 0 || len(projects) == 0 {
+		return nil
+	}
+	if filterErr != nil {
+		return filterErr
+	}
+	return fmt.Errorf("no project could be read: %w", lastErr)
+}
+
+// check a filter's syntax once before running it in several projects,
+// leaving state names to each project's workflow
+func checkFilterSyntax(expr string) error {
+	if _, err := filter.Parse(expr); err != nil && !errors.Is(err, filter.ErrUnknownState) {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	return nil
+}
+
+// project aliases with the @ prefix that match toComplete
+func projectCompletions(toComplete string) []cobra.Completion {
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		return nil
+	}
+	// complete the last name in a comma list
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix, toComplete = toComplete[:i+1], toComplete[i+1:]
+	}
+	completions := []cobra.Completion{}
+	for _, p := range reg.Projects {
+		candidate := viewPrefix + p.Alias
+		if strings.HasPrefix(candidate, toComplete) {
+			completions = append(completions, cobra.CompletionWithDesc(prefix+candidate, p.Path))
+		}
+	}
+	return completions
+}
+
+// complete a project alias for projects subcommands
+func projectArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := projectCompletions(viewPrefix + toComplete)
+	for i, completion := range completions {
+		completions[i] = cobra.Completion(strings.TrimPrefix(string(completion), viewPrefix))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"punchlist/config"
+	"punchlist/task"
+	"strings"
+	"testing"
+	"time"
+)
+
+// init a project in a new folder and add tasks to it
+func initProjectIn(t *testing.T, dir string, titles ...string) {
+	t.Helper()
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", dir, err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to enter %s: %v", dir, err)
+	}
+	defer os.Chdir(wd)
+	if _, err := executeCommand("init"); err != nil {
+		t.Fatalf("init failed in %s: %v", dir, err)
+	}
+	for _, title := range titles {
+		executeCommand(title)
+	}
+}
+
+// test that init registers projects and that they can be renamed and removed
+func TestProjectsCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	initProjectIn(t, "work", "Ship release")
+	initProjectIn(t, "home", "Fix sink", "Call plumber")
+
+	output, _ := executeCommand("projects")
+	for _, expected := range []string{"@work", "@home", "(1 open)", "(2 open)"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in projects list:\n%s", expected, output)
+		}
+	}
+
+	executeCommand("projects", "alias", "home", "house")
+	reg, err := config.LoadRegistry()
+	if err != nil {
+		t.Fatalf("Failed to load registry: %v", err)
+	}
+	if _, ok := reg.Find("house"); !ok {
+		t.Errorf("Expected home to be renamed to house: %+v", reg.Projects)
+	}
+
+	output, _ = executeCommand("projects", "alias", "house", "Bad Alias")
+	if !strings.Contains(output, "invalid alias") {
+		t.Errorf("Expected invalid alias error, got %q", output)
+	}
+
+	executeCommand("projects", "rm", "@work")
+	reg, _ = config.LoadRegistry()
+	if len(reg.Projects) != 1 {
+		t.Errorf("Expected one project after rm, got %+v", reg.Projects)
+	}
+	if _, err := os.Stat("work/.punchlist"); err != nil {
+		t.Errorf("Expected rm to leave the project's files alone: %v", err)
+	}
+}
+
+// test listing tasks merged from several projects
+func TestLsAcrossProjects(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	initProjectIn(t, "work", "Ship release")
+	initProjectIn(t, "home", "Fix sink")
+	initProjectIn(t, "garden", "Plant beans")
+
+	output, _ := executeCommand("ls", "@work,@home")
+	if !strings.Contains(output, "work ") || !strings.Contains(output, "Ship release") ||
+		!strings.Contains(output, "home ") || !strings.Contains(output, "Fix sink") {
+		t.Errorf("Expected tasks prefixed by project:\n%s", output)
+	}
+	if strings.Contains(output, "Plant beans") {
+		t.Errorf("Expected only the named projects:\n%s", output)
+	}
+
+	output, _ = executeCommand("ls", "--all", "title~sink")
+	if !strings.Contains(output, "Fix sink") || strings.Contains(output, "Ship release") {
+		t.Errorf("Expected --all to apply the filter in every project:\n%s", output)
+	}
+
+	output, _ = executeCommand("ls", "--all", "--format", "json")
+	var records []taskRecord
+	if err := json.Unmarshal([]byte(output), &records); err != nil {
+		t.Fatalf("Expected json output: %v\n%s", err, output)
+	}
+	projects := map[string]bool{}
+	for _, record := range records {
+		projects[record.Project] = true
+	}
+	if len(records) != 3 || !projects["work"] || !projects["home"] || !projects["garden"] {
+		t.Errorf("Expected a project on every record: %+v", records)
+	}
+
+	output, _ = executeCommand("ls", "@work,@home", "--template", "{{.Project}}: {{.Title}}")
+	if !strings.Contains(output, "work: Ship release\n") || !strings.Contains(output, "home: Fix sink\n") {
+		t.Errorf("Expected .Project in templates across projects:\n%s", output)
+	}
+
+	output, _ = executeCommand("ls", "@work,@nowhere")
+	if !strings.Contains(output, "unknown project @nowhere") {
+		t.Errorf("Expected unknown project error, got %q", output)
+	}
+}
+
+// add a REVIEW state to the project in dir
+func addReviewState(t *testing.T, dir string) {
+	t.Helper()
+	cfg, err := config.LoadConfigFrom(dir)
+	if err != nil {
+		t.Fatalf("Failed to load config in %s: %v", dir, err)
+	}
+	cfg.States = []config.StateConfig{{Name: "REVIEW"}}
+	cfg.Transitions = map[string][]string{"TODO": {"REVIEW"}, "REVIEW": {"DONE"}}
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save config in %s: %v", dir, err)
+	}
+}
+
+// test that filters across projects are checked once and states are left to
+// each project's workflow
+func TestFilterAcrossProjects(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	defer task.SetWorkflow(nil)
+
+	initProjectIn(t, "work", "Ship release")
+	initProjectIn(t, "home", "Fix sink")
+	addReviewState(t, "work")
+	withWorkingDir("work", func() error {
+		_, err := executeCommand("move", "1", "review")
+		return err
+	})
+
+	output, _ := executeCommand("ls", "--all", "pri:>")
+	if strings.Count(output, "invalid filter") != 1 {
+		t.Errorf("Expected one invalid filter error, got %q", output)
+	}
+
+	output, _ = executeCommand("ls", "--all", "state:REVIEW")
+	if !strings.Contains(output, "Ship release") || strings.Contains(output, "Fix sink") || strings.Contains(output, "Error") {
+		t.Errorf("Expected the REVIEW task from work only, got %q", output)
+	}
+
+	output, _ = executeCommand("ls", "--all", "state:LIMBO")
+	if strings.Count(output, "invalid filter: state:LIMBO: unknown state LIMBO") != 1 {
+		t.Errorf("Expected one unknown state error when no project knows it, got %q", output)
+	}
+}
+
+// test agenda across registered projects
+func TestAgendaAcrossProjects(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	yesterday := "by:" + time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	initProjectIn(t, "work")
+	initProjectIn(t, "home")
+	for _, dir := range []string{"work", "home"} {
+		os.Chdir(dir)
+		executeCommand("todo", "Late in "+dir, yesterday)
+		os.Chdir("..")
+	}
+
+	output, err := executeCommand("agenda", "--all")
+	var exitErr exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 1 {
+		t.Errorf("Expected exit status 1 with overdue tasks, got %v", err)
+	}
+	if !strings.Contains(output, "Overdue (2)") || !strings.Contains(output, "work ") || !strings.Contains(output, "Late in home") {
+		t.Errorf("Expected overdue tasks from both projects:\n%s", output)
+	}
+}
+
+// test that a name used by both a saved view and a project is refused
+func TestViewAndProjectNameClash(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	initProjectIn(t, "work", "Ship release")
+	initProjectIn(t, "home", "Fix sink")
+	os.Chdir("home")
+	defer os.Chdir("..")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.Views = map[string]config.ViewConfig{"work": {Filter: "open"}, "chores": {Filter: "open"}}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	output, _ := executeCommand("ls", "@work")
+	if !strings.Contains(output, "@work is both a saved view and a registered project") || strings.Contains(output, "Fix sink") {
+		t.Errorf("Expected an error naming the clash, got %q", output)
+	}
+	output, _ = executeCommand("ls", "@chores")
+	if !strings.Contains(output, "Fix sink") {
+		t.Errorf("Expected a view without a matching project to still work, got %q", output)
+	}
+}
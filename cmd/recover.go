@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/fsutil"
+)
+
+// clean up temp files left by interrupted writes in the active project
+func recoverInterruptedWrites() {
+	root, err := punchlistRoot()
+	if err != nil {
+		return
+	}
+
+	dirs := []string{
+		filepath.Join(root, "tasks"),
+		filepath.Join(root, config.PunchlistDir),
+	}
+	for _, dir := range dirs {
+		kept, err := fsutil.RecoverDir(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not recover interrupted writes: %v\n", err)
+			continue
+		}
+		// the target never landed, so the temp file may be the only copy
+		for _, orphan := range kept {
+			fmt.Fprintf(os.Stderr,
+				"Warning: %s was left by an interrupted write and %s is missing; check it and rename it into place if complete\n",
+				orphan.Path, filepath.Base(orphan.Target))
+		}
+	}
+}
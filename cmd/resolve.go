@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"punchlist/task"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// a task file on disk with its parsed frontmatter
+type taskFileEntry struct {
+	path      string
+	task      *task.Task
+	parseErr  error
+	prefixID  int
+	hasPrefix bool
+}
+
+// index of task files keyed by their frontmatter id
+type taskIndex struct {
+	entries []*taskFileEntry
+	byID    map[int][]*taskFileEntry
+}
+
+// read every task file in tasksPath into an index
+func loadTaskIndex(tasksPath string) (*taskIndex, error) {
+	entries, err := loadTaskFiles(tasksPath)
+	if err != nil {
+		return nil, err
+	}
+	idx := &taskIndex{entries: entries, byID: map[int][]*taskFileEntry{}}
+	for _, entry := range entries {
+		if entry.parseErr == nil {
+			idx.byID[entry.task.ID] = append(idx.byID[entry.task.ID], entry)
+		}
+	}
+	return idx, nil
+}
+
+// parse every markdown file under tasksPath, skipping hidden files such as
+// temp and staging files; parse failures are kept on the entry
+func loadTaskFiles(tasksPath string) ([]*taskFileEntry, error) {
+	entries := []*taskFileEntry{}
+	err := walkTaskFiles(tasksPath, func(path string, d fs.DirEntry) error {
+		entry := &taskFileEntry{path: path}
+		entry.prefixID, entry.hasPrefix = filenameID(d.Name())
+		entry.task, entry.parseErr = task.Parse(path)
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// call fn for every markdown task file under tasksPath without reading it,
+// skipping hidden files and folders; a missing folder has no files
+func walkTaskFiles(tasksPath string, fn func(path string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(tasksPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == tasksPath && os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != tasksPath && strings.HasPrefix(name, ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".md") || strings.HasPrefix(name, ".") {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+// read the numeric id prefix of a task filename
+func filenameID(name string) (int, bool) {
+	prefix := strings.SplitN(name, "-", 2)[0]
+	id, err := strconv.Atoi(strings.TrimSuffix(prefix, ".md"))
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// ids claimed by either frontmatter or filename
+func (idx *taskIndex) usedIDs() map[int]bool {
+	used := make(map[int]bool, len(idx.entries))
+	for _, entry := range idx.entries {
+		if entry.hasPrefix {
+			used[entry.prefixID] = true
+		}
+		if entry.parseErr == nil {
+			used[entry.task.ID] = true
+		}
+	}
+	return used
+}
+
+// ids that more than one file claims in frontmatter, ascending
+func (idx *taskIndex) duplicateIDs() []int {
+	ids := []int{}
+	for id, entries := range idx.byID {
+		if len(entries) > 1 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// find the single file whose frontmatter id matches, treating the
+// filename as a hint only
+func (idx *taskIndex) resolve(id int) (*taskFileEntry, error) {
+	matches := idx.byID[id]
+	if len(matches) > 1 {
+		names := make([]string, 0, len(matches))
+		for _, entry := range matches {
+			names = append(names, filepath.Base(entry.path))
+		}
+		return nil, fmt.Errorf("task ID %d is used by more than one file: %s; run pin doctor --fix to renumber all but one", id, strings.Join(names, ", "))
+	}
+	if len(matches) == 1 {
+		entry := matches[0]
+		if entry.hasPrefix && entry.prefixID != id {
+			fmt.Fprintf(os.Stderr, "Warning: %s has id %d in its frontmatter but %d in its filename\n",
+				filepath.Base(entry.path), id, entry.prefixID)
+		}
+		return entry, nil
+	}
+
+	// explain near misses where the filename suggests this id
+	for _, entry := range idx.entries {
+		if !entry.hasPrefix || entry.prefixID != id {
+			continue
+		}
+		if entry.parseErr != nil {
+			return nil, fmt.Errorf("task with ID %d not found; %s looks like a match but could not be parsed: %v",
+				id, filepath.Base(entry.path), entry.parseErr)
+		}
+		return nil, fmt.Errorf("task with ID %d not found; %s has id %d in its frontmatter",
+			id, filepath.Base(entry.path), entry.task.ID)
+	}
+	return nil, fmt.Errorf("task with ID %d not found", id)
+}
+
+// locate a task file by its frontmatter id
+func findTaskFile(id int) (string, error) {
+	tasksPath, err := tasksDir()
+	if err != nil {
+		return "", err
+	}
+	idx, err := heldTaskIndex(tasksPath)
+	if err != nil {
+		return "", err
+	}
+	entry, err := idx.resolve(id)
+	if err != nil {
+		return "", err
+	}
+	return entry.path, nil
+}
+
+// the task index for tasksPath; while this process holds the project lock
+// it's built once and only the files changed since are parsed again, so bulk
+// commands don't read every task per id
+func heldTaskIndex(tasksPath string) (*taskIndex, error) {
+	root, err := punchlistRoot()
+	if err != nil {
+		return nil, err
+	}
+	held := heldLocks[root]
+	if held == nil {
+		return loadTaskIndex(tasksPath)
+	}
+	if held.index == nil {
+		if held.index, err = loadTaskIndex(tasksPath); err != nil {
+			return nil, err
+		}
+		held.changed = map[string]bool{}
+		return held.index, nil
+	}
+	if len(held.changed) > 0 {
+		held.index.refresh(tasksPath, held.changed)
+		held.changed = map[string]bool{}
+	}
+	return held.index, nil
+}
+
+// parse changed paths again, dropping files that are gone and adding new
+// ones, then rebuild the id lookup
+func (idx *taskIndex) refresh(tasksPath string, changed map[string]bool) {
+	entries := []*taskFileEntry{}
+	for _, entry := range idx.entries {
+		if !changed[filepath.Clean(entry.path)] {
+			entries = append(entries, entry)
+		}
+	}
+	for path := range changed {
+		if !isTaskFilePath(tasksPath, path) {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		entry := &taskFileEntry{path: path}
+		entry.prefixID, entry.hasPrefix = filenameID(filepath.Base(path))
+		entry.task, entry.parseErr = task.Parse(path)
+		entries = append(entries, entry)
+	}
+	idx.entries = entries
+	idx.byID = map[int][]*taskFileEntry{}
+	for _, entry := range entries {
+		if entry.parseErr == nil {
+			idx.byID[entry.task.ID] = append(idx.byID[entry.task.ID], entry)
+		}
+	}
+}
+
+// report whether walkTaskFiles would visit path: a markdown file under
+// tasksPath with no hidden folder or name on the way
+func isTaskFilePath(tasksPath, path string) bool {
+	rel, err := filepath.Rel(tasksPath, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") || !strings.HasSuffix(rel, ".md") {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return true
+}
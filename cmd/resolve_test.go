@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"punchlist/fsutil"
+	"punchlist/task"
+	"strings"
+	"testing"
+)
+
+// test that tasks resolve by frontmatter id rather than filename
+func TestResolveTaskByFrontmatterID(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	tasksPath, err := tasksDir()
+	if err != nil {
+		t.Fatalf("Failed to resolve tasks dir: %v", err)
+	}
+
+	// a renamed file whose prefix no longer matches its id
+	renamed := &task.Task{ID: 4, Title: "Renamed", State: task.StateTodo}
+	renamed.Write(filepath.Join(tasksPath, "009-renamed.md"))
+	// two files claiming the same id
+	first := &task.Task{ID: 5, Title: "First", State: task.StateTodo}
+	first.Write(filepath.Join(tasksPath, "005-first.md"))
+	second := &task.Task{ID: 5, Title: "Second", State: task.StateTodo}
+	second.Write(filepath.Join(tasksPath, "005-second.md"))
+
+	path, err := findTaskFile(4)
+	if err != nil {
+		t.Fatalf("Expected task 4 to resolve, got %v", err)
+	}
+	if filepath.Base(path) != "009-renamed.md" {
+		t.Errorf("Expected 009-renamed.md, got %s", path)
+	}
+
+	if _, err := findTaskFile(9); err == nil || !strings.Contains(err.Error(), "has id 4") {
+		t.Errorf("Expected a not found error pointing at id 4, got %v", err)
+	}
+
+	if _, err := findTaskFile(5); err == nil || !strings.Contains(err.Error(), "more than one file") {
+		t.Errorf("Expected a duplicate id error, got %v", err)
+	}
+
+	if _, err := executeCommand("done", "4"); err != nil {
+		t.Fatalf("done command failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(tasksPath, "009-renamed.md"))
+	if err != nil {
+		t.Fatalf("Failed to read task file: %v", err)
+	}
+	if !strings.Contains(string(content), "state: DONE") {
+		t.Errorf("Expected renamed task to be marked done. File content:\n%s", content)
+	}
+}
+
+// test that ids resolve against one index per held lock, parsing again only
+// the files changed since it was built
+func TestHeldTaskIndex(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	for i := 0; i < 20; i++ {
+		executeCommand("todo", "Task")
+	}
+	root, _ := punchlistRoot()
+
+	err := withProjectLock(func() error {
+		first, err := findTaskFile(1)
+		if err != nil {
+			return err
+		}
+		idx := heldLocks[root].index
+		if idx == nil {
+			t.Fatalf("Expected the index to be kept while the lock is held")
+		}
+		parsed := map[*task.Task]bool{}
+		for _, entry := range idx.entries {
+			parsed[entry.task] = true
+		}
+
+		renamed := filepath.Join(filepath.Dir(first), "001-renamed.md")
+		if err := fsutil.Rename(first, renamed); err != nil {
+			return err
+		}
+		for id := 1; id <= 20; id++ {
+			path, err := findTaskFile(id)
+			if err != nil {
+				return err
+			}
+			if id == 1 && path != renamed {
+				t.Errorf("Expected task 1 at its new path, got %s", path)
+			}
+		}
+		if heldLocks[root].index != idx {
+			t.Errorf("Expected one index for the whole command")
+		}
+		reparsed := 0
+		for _, entry := range idx.entries {
+			if !parsed[entry.task] {
+				reparsed++
+			}
+		}
+		if len(idx.entries) != 20 || reparsed != 1 {
+			t.Errorf("Expected only the renamed file to be parsed again, got %d of %d", reparsed, len(idx.entries))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("lookups failed: %v", err)
+	}
+	if _, ok := heldLocks[root]; ok {
+		t.Errorf("Expected the index to be dropped with the lock")
+	}
+}
+
+// test that a copy of a task claiming its id stops bulk commands
+func TestResolveRefusesCopiedTask(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Alpha")
+	executeCommand("todo", "Beta")
+	tasksPath, _ := tasksDir()
+	original, _ := os.ReadFile(filepath.Join(tasksPath, "001-alpha.md"))
+	os.WriteFile(filepath.Join(tasksPath, "009-copy.md"), original, 0644)
+
+	output, _ := executeCommand("done", "1-2")
+	if !strings.Contains(output, "task ID 1 is used by more than one file: 001-alpha.md, 009-copy.md") {
+		t.Errorf("Expected a duplicate id error, got %q", output)
+	}
+	if !strings.Contains(output, "Task 2 moved to DONE") {
+		t.Errorf("Expected the other task to still be updated, got %q", output)
+	}
+	if content, _ := os.ReadFile(filepath.Join(tasksPath, "001-alpha.md")); string(content) != string(original) {
+		t.Errorf("Expected 001-alpha.md to be left alone:\n%s", content)
+	}
+}
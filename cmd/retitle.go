@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/task"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// create the retitle command
+func newRetitleCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "retitle <id> <title>",
+		Short: "Change a task's title and rename its file to match",
+		Long: `Change a task's title in its frontmatter and leading # heading, rename the
+file to the new slug, and log the old title:
+  pin retitle 12 "Ship release notes"
+
+If another file already has the new name, the task keeps its old filename and
+the clash is reported; pin doctor lists it until it is resolved.`,
+		Args: cobra.MinimumNArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return taskIDCompletions(task.ActiveWorkflow().Names(), toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				fmt.Printf("Invalid task ID: %v\n", err)
+				return
+			}
+			title := strings.TrimSpace(strings.Join(args[1:], " "))
+			if title == "" {
+				fmt.Println("Error retitling task: missing title")
+				return
+			}
+			if err := withProjectLock(func() error { return retitleTask(id, title) }); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error retitling task: %v\n", err)
+			}
+		},
+	}
+}
+
+// change a task's title, rename its file and report both
+func retitleTask(id int, title string) error {
+	taskPath, err := findTaskFile(id)
+	if err != nil {
+		return err
+	}
+	t, err := task.Parse(taskPath)
+	if err != nil {
+		return fmt.Errorf("error parsing task: %w", err)
+	}
+	if t.Title == title {
+		fmt.Printf("Task %d is already titled %q.\n", id, title)
+		return nil
+	}
+
+	now := time.Now()
+	msg := setTaskTitle(t, title)
+	t.Body = appendLogEntry(t.Body, msg, now)
+	t.UpdatedAt = now
+	if err := t.Write(taskPath); err != nil {
+		return err
+	}
+	fmt.Printf("Task %d %s\n", id, msg)
+	renameRetitledTask(t, taskPath)
+	return nil
+}
+
+// rename a retitled task's file to match and report it, keeping the old
+// name when another file has the new one
+func renameRetitledTask(t *task.Task, taskPath string) {
+	renamed, err := renameTaskFile(t)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; kept %s\n", err, filepath.Base(taskPath))
+	} else if renamed != taskPath {
+		fmt.Printf("Renamed %s to %s\n", filepath.Base(taskPath), filepath.Base(renamed))
+	}
+}
+
+// change a task's title and the leading # heading in its body, returning
+// the log message
+func setTaskTitle(t *task.Task, title string) string {
+	msg := fmt.Sprintf("title changed from %q to %q", t.Title, title)
+	t.Title = title
+	heading, rest, _ := strings.Cut(t.Body, "\n")
+	if strings.HasPrefix(heading, "# ") {
+		t.Body = "# " + title
+		if rest != "" {
+			t.Body += "\n" + rest
+		}
+	}
+	return msg
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"punchlist/task"
+	"strings"
+	"testing"
+)
+
+// test retitling a task, its heading and its file
+func TestRetitleCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("log", "1", "reproduced on staging")
+
+	output, err := executeCommand("retitle", "1", "Fix", "signup", "bug")
+	if err != nil {
+		t.Fatalf("retitle failed: %v", err)
+	}
+	for _, expected := range []string{`Task 1 title changed from "Fix login bug" to "Fix signup bug"`, "Renamed 001-fix-login-bug.md to 001-fix-signup-bug.md"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in output, got %q", expected, output)
+		}
+	}
+
+	path, err := findTaskFile(1)
+	if err != nil || filepath.Base(path) != "001-fix-signup-bug.md" {
+		t.Fatalf("Expected the renamed file, got %s %v", path, err)
+	}
+	retitled, err := task.Parse(path)
+	if err != nil {
+		t.Fatalf("Failed to parse task: %v", err)
+	}
+	if retitled.Title != "Fix signup bug" || !strings.HasPrefix(retitled.Body, "# Fix signup bug\n") {
+		t.Errorf("Expected the title and heading to change, got %q:\n%s", retitled.Title, retitled.Body)
+	}
+	for _, expected := range []string{"reproduced on staging", `title changed from "Fix login bug"`} {
+		if !strings.Contains(retitled.Body, expected) {
+			t.Errorf("Expected %q in body:\n%s", expected, retitled.Body)
+		}
+	}
+
+	output, _ = executeCommand("retitle", "1", "Fix signup bug")
+	if !strings.Contains(output, "already titled") {
+		t.Errorf("Expected no change, got %q", output)
+	}
+}
+
+// test that a file already at the new name is reported, not replaced
+func TestRetitleCollision(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	clash := filepath.Join("tasks", "001-plan-offsite.md")
+	if err := os.WriteFile(clash, []byte("notes, not a task\n"), 0644); err != nil {
+		t.Fatalf("Failed to write clashing file: %v", err)
+	}
+
+	output, err := executeCommand("retitle", "1", "Plan offsite")
+	if err != nil || strings.Contains(output, "Renamed") {
+		t.Errorf("Expected no rename, got %q %v", output, err)
+	}
+	if content, _ := os.ReadFile(clash); string(content) != "notes, not a task\n" {
+		t.Errorf("Expected the clashing file to be left alone, got %q", content)
+	}
+	path := filepath.Join("tasks", "001-fix-login-bug.md")
+	if retitled, err := task.Parse(path); err != nil || retitled.Title != "Plan offsite" {
+		t.Errorf("Expected the title to change in the old file, got %v %v", retitled, err)
+	}
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"punchlist/config"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -35,10 +37,13 @@ List and modify tasks:
   pin ls ../work
   pin ls todo --tag launch
   pin due 12 "next tuesday"
+  pin set 12 pri:1 +urgent -later
   pin log 12 "sent draft to team"
   pin note 12 "ask for feedback from legal"
   pin del 12
+  pin restore 12
   pin compact
+  pin undo
 
 Zsh cwd hook snippet (optional, for prompt or env):
   autoload -U add-zsh-hook
@@ -57,13 +62,27 @@ Zsh cwd hook snippet (optional, for prompt or env):
   _pin_set_root`
 
 	cmd := &cobra.Command{
-		Use:     "pin",
-		Aliases: []string{"punchlist"},
-		Short:   "A text-native, AI-friendly task and ticket system.",
-		Long:    longDesc,
+		Use:               "pin",
+		Aliases:           []string{"punchlist"},
+		Short:             "A text-native, AI-friendly task and ticket system.",
+		Long:              longDesc,
 		ValidArgsFunction: rootArgCompletion,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if rootFlag, _ := cmd.Flags().GetString("root"); rootFlag != "" {
+				config.SetRootOverride(rootFlag)
+			}
+			colorFlag, _ := cmd.Flags().GetString("color")
+			if err := setColorMode(colorFlag); err != nil {
+				return err
+			}
+			applyProjectWorkflow()
+			return nil
+		},
 	}
 
+	cmd.PersistentFlags().String("root", "", "Use the punchlist project at this path")
+	cmd.PersistentFlags().String("color", colorAuto, "Use colors: auto, always, or never")
+
 	cmd.AddCommand(newInitCmd())
 	cmd.AddCommand(newLsCmd())
 	cmd.AddCommand(newStartCmd())
@@ -71,6 +90,7 @@ Zsh cwd hook snippet (optional, for prompt or env):
 	cmd.AddCommand(newDeferCmd())
 	cmd.AddCommand(newBlockCmd())
 	cmd.AddCommand(newConfirmCmd())
+	cmd.AddCommand(newMoveCmd())
 	cmd.AddCommand(newDeleteCmd())
 	cmd.AddCommand(newCompactCmd())
 	cmd.AddCommand(newLogCmd())
@@ -78,6 +98,22 @@ Zsh cwd hook snippet (optional, for prompt or env):
 	cmd.AddCommand(newNoteCmd())
 	cmd.AddCommand(newShowCmd())
 	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newDoctorCmd())
+	cmd.AddCommand(newSearchCmd())
+	cmd.AddCommand(newViewCmd())
+	cmd.AddCommand(newAgendaCmd())
+	cmd.AddCommand(newProjectsCmd())
+	cmd.AddCommand(newTreeCmd())
+	cmd.AddCommand(newTuiCmd())
+	cmd.AddCommand(newBoardCmd())
+	cmd.AddCommand(newEditCmd())
+	cmd.AddCommand(newSetCmd())
+	cmd.AddCommand(newRetitleCmd())
+	cmd.AddCommand(newUndoCmd())
+	cmd.AddCommand(newRedoCmd())
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newTrashCmd())
+	cmd.AddCommand(newRestoreCmd())
 
 	// keep completion available but hidden from help
 	cmd.CompletionOptions.HiddenDefaultCmd = true
@@ -90,7 +126,16 @@ Zsh cwd hook snippet (optional, for prompt or env):
 func Execute() {
 	// build the root command tree
 	root := NewRootCmd()
-	args := os.Args[1:]
+	rootFlag, args, err := splitRootFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Whoops. There was an error while executing your CLI '%s'", err)
+		os.Exit(1)
+	}
+	if rootFlag != "" {
+		config.SetRootOverride(rootFlag)
+	}
+	setCommandLine(args)
+	recoverInterruptedWrites()
 	if len(args) > 0 && !strings.HasPrefix(args[0], "-") && !isSubcommand(root, args[0]) && !isCobraCompletionCmd(args[0]) {
 		// treat bare args as task creation
 		if err := createTaskFromArgs(args); err != nil {
@@ -104,7 +149,11 @@ func Execute() {
 	}
 
 	// run cobra command execution
-	if err := root.Execute(); err != nil {
+	if err := executeRootCmd(root); err != nil {
+		var exitErr exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		if printNotPunchlistError(err) {
 			os.Exit(1)
 		}
@@ -113,6 +162,31 @@ func Execute() {
 	}
 }
 
+// run the command tree, then put back the root override so a --root given to
+// this run doesn't carry over to the next one in the same process
+func executeRootCmd(root *cobra.Command) error {
+	previous := config.RootOverride()
+	defer config.SetRootOverride(previous)
+	return root.Execute()
+}
+
+// pull a leading --root flag off the args so implicit creation sees it
+func splitRootFlag(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", args, nil
+	}
+	if value, ok := strings.CutPrefix(args[0], "--root="); ok {
+		return value, args[1:], nil
+	}
+	if args[0] == "--root" {
+		if len(args) < 2 {
+			return "", nil, fmt.Errorf("missing value for --root")
+		}
+		return args[1], args[2:], nil
+	}
+	return "", args, nil
+}
+
 // check if a token matches a subcommand name or alias
 func isSubcommand(root *cobra.Command, name string) bool {
 	for _, cmd := range root.Commands() {
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/search"
+	"punchlist/task"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// search index file inside .punchlist
+const searchIndexFile = "search-index.json"
+
+// create the search command
+func newSearchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search [flags] <terms>",
+		Short: "Search task titles, bodies, notes and logs",
+		Long: `Search task titles, bodies, notes and logs, best matches first.
+
+Every word must appear. Quote phrases, scope a word to one field, or exclude it:
+  pin search release notes
+  pin search '"release notes"' title:launch
+  pin search log:deployed -staging
+
+Fields: title, tags, body, notes, log. Flags go before the terms so that
+-word is read as an exclusion.
+
+Set search_index: true in .punchlist/config.yaml to keep an index in
+.punchlist/search-index.json that is updated as files change.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			limit, _ := cmd.Flags().GetInt("limit")
+			reindex, _ := cmd.Flags().GetBool("reindex")
+			if err := searchTasks(strings.Join(args, " "), limit, reindex); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error searching tasks: %v\n", err)
+			}
+		},
+	}
+	// stop at the first term so -word isn't taken for a flag
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().IntP("limit", "n", 20, "Show at most this many results (0 for all)")
+	cmd.Flags().Bool("reindex", false, "Rebuild the search index from scratch")
+	return cmd
+}
+
+// search the active project and print ranked results with snippets
+func searchTasks(input string, limit int, reindex bool) error {
+	query, err := search.ParseQuery(input)
+	if err != nil {
+		return fmt.Errorf("invalid search: %w", err)
+	}
+	root, err := punchlistRoot()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfigFrom(root)
+	if err != nil {
+		return err
+	}
+	if reindex && !cfg.SearchIndex {
+		return fmt.Errorf("the search index is off; set search_index: true in %s to use it",
+			filepath.Join(config.PunchlistDir, "config.yaml"))
+	}
+
+	tasksPath := filepath.Join(root, "tasks")
+	files, err := searchFiles(tasksPath)
+	if err != nil {
+		return err
+	}
+
+	// parse each file at most once per run
+	docs := map[string]search.Document{}
+	load := func(path string) (search.Document, error) {
+		if doc, ok := docs[path]; ok {
+			return doc, nil
+		}
+		fullPath := filepath.Join(tasksPath, filepath.FromSlash(path))
+		t, err := task.Parse(fullPath)
+		if err != nil {
+			return search.Document{}, fmt.Errorf("%s: %w", fullPath, err)
+		}
+		doc := search.FromTask(t)
+		docs[path] = doc
+		return doc, nil
+	}
+
+	index := search.NewIndex()
+	indexPath := filepath.Join(root, config.PunchlistDir, searchIndexFile)
+	if cfg.SearchIndex && !reindex {
+		if index, err = search.LoadIndex(indexPath); err != nil {
+			return fmt.Errorf("reading search index: %w", err)
+		}
+	}
+	for _, err := range index.Update(files, load) {
+		fmt.Fprintf(os.Stderr, "Error parsing task file %v\n", err)
+	}
+	if cfg.SearchIndex && index.Changed() {
+		if err := index.Save(indexPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not save search index: %v\n", err)
+		}
+	}
+
+	results := index.Search(query, load, limit)
+	if len(results) == 0 {
+		fmt.Println("No matching tasks.")
+		return nil
+	}
+	printSearchResults(results, query)
+	return nil
+}
+
+// list task files with the size and time the index compares
+func searchFiles(tasksPath string) ([]search.File, error) {
+	files := []search.File{}
+	err := walkTaskFiles(tasksPath, func(path string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(tasksPath, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, search.File{
+			Path:    filepath.ToSlash(rel),
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		})
+		return nil
+	})
+	return files, err
+}
+
+// print each result's id, state and title, then a snippet of the match
+func printSearchResults(results []search.Result, query *search.Query) {
+	idWidth := loadIDWidth()
+	for _, result := range results {
+		if digits := len(fmt.Sprintf("%d", result.Doc.ID)); digits > idWidth {
+			idWidth = digits
+		}
+	}
+	color := colorEnabled()
+	indent := strings.Repeat(" ", idWidth+1)
+	for _, result := range results {
+		title := highlightRanges(result.Doc.Title, search.Highlight(result.Doc.Title, query, search.FieldTitle), color)
+		fmt.Printf("%*d %s %s\n", idWidth, result.Doc.ID, result.Doc.State, title)
+		if result.Snippet.Field != search.FieldTitle {
+			fmt.Printf("%s%s: %s\n", indent, result.Snippet.Field,
+				highlightRanges(result.Snippet.Text, result.Snippet.Highlights, color))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"strings"
+	"testing"
+)
+
+// test pin search with and without the on-disk index
+func TestSearchCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Release checklist")
+	executeCommand("todo", "Write docs")
+	executeCommand("todo", "Plan party")
+	executeCommand("note", "2", "mention the release notes")
+	executeCommand("log", "3", "booked the venue")
+
+	output, _ := executeCommand("search", "release")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 3 || strings.TrimSpace(lines[0]) != "1 TODO Release checklist" || strings.TrimSpace(lines[1]) != "2 TODO Write docs" {
+		t.Fatalf("Unexpected search output:\n%s", output)
+	}
+	if !strings.Contains(lines[2], "notes: ") || !strings.Contains(lines[2], "release notes") {
+		t.Errorf("Expected a notes snippet, got %q", lines[2])
+	}
+
+	output, _ = executeCommand("search", "log:venue")
+	if !strings.HasPrefix(strings.TrimSpace(output), "3 TODO Plan party") {
+		t.Errorf("Expected field-scoped match, got:\n%s", output)
+	}
+	output, _ = executeCommand("search", "release", "-notes")
+	if strings.Contains(output, "Write docs") || !strings.Contains(output, "Release checklist") {
+		t.Errorf("Expected exclusion to drop task 2, got:\n%s", output)
+	}
+	output, _ = executeCommand("search", "nothing")
+	if !strings.Contains(output, "No matching tasks.") {
+		t.Errorf("Expected no results, got:\n%s", output)
+	}
+
+	// turn the index on and check it follows file changes
+	cfg, _ := config.LoadConfig()
+	cfg.SearchIndex = true
+	config.SaveConfig(cfg)
+	root, _ := config.FindPunchlistRoot()
+	indexPath := filepath.Join(root, config.PunchlistDir, searchIndexFile)
+
+	executeCommand("search", "release")
+	if _, err := os.Stat(indexPath); err != nil {
+		t.Fatalf("Expected search index to be written: %v", err)
+	}
+	executeCommand("note", "3", "release the balloons")
+	output, _ = executeCommand("search", "balloons")
+	if !strings.HasPrefix(strings.TrimSpace(output), "3 TODO Plan party") {
+		t.Errorf("Expected index to pick up the new note, got:\n%s", output)
+	}
+	executeCommand("del", "3")
+	output, _ = executeCommand("search", "balloons")
+	if !strings.Contains(output, "No matching tasks.") {
+		t.Errorf("Expected deleted task to leave the index, got:\n%s", output)
+	}
+	output, _ = executeCommand("search", "--reindex", "release")
+	if !strings.HasPrefix(strings.TrimSpace(output), "1 TODO Release checklist") {
+		t.Errorf("Expected results after reindex, got:\n%s", output)
+	}
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"punchlist/config"
+	"punchlist/task"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// modifier that sets a task's title with pin set
+const titleModifier = "title:"
+
+// changes pin set makes to each task
+type setChanges struct {
+	title    string
+	priority *int
+	due      *time.Time
+	clearDue bool
+	// tags:{a,b} replaces every tag before +tag and -tag apply
+	replaceTags bool
+	tags        []string
+	addTags     []string
+	removeTags  []string
+}
+
+// create the set command
+func newSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <ids> <modifiers>",
+		Short: "Change priority, due date, tags or title of tasks",
+		Long: `Change fields of one or more tasks with the modifiers used when creating one,
+logging each changed field in the task:
+  pin set 12 pri:1
+  pin set 3 5-9 pri:1 +urgent -later by:friday
+  pin set 12 title:"Ship release notes"
+
+Modifiers:
+  pri:n, priority:n    set the priority, 0 or none to clear it
+  due:date, by:date    set the due date, none to clear it
+  tags:{a,b}           replace all tags, none to clear them
+  +tag, -tag           add or remove one tag
+  title:text           change the title and heading, renaming the file to match
+
+Quote values with spaces, or put them after a bare key: by next friday.`,
+		Args: cobra.MinimumNArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			for _, arg := range args {
+				if isSetModifier(arg) {
+					return nil, cobra.ShellCompDirectiveNoFileComp
+				}
+			}
+			return taskIDCompletions(task.ActiveWorkflow().Names(), toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			ids, changes, err := parseSetArgs(args)
+			if err != nil {
+				fmt.Printf("Invalid arguments: %v\n", err)
+				return
+			}
+			setTasks(ids, changes)
+		},
+	}
+	// stop at the first id so -tag isn't taken for a flag
+	cmd.Flags().SetInterspersed(false)
+	return cmd
+}
+
+// split args into the leading task ids and the modifiers after them
+func parseSetArgs(args []string) ([]int, setChanges, error) {
+	split := len(args)
+	for i, arg := range args {
+		if isSetModifier(arg) {
+			split = i
+			break
+		}
+	}
+	if split == len(args) {
+		return nil, setChanges{}, fmt.Errorf("nothing to set; use pri:, due:, tags:, +tag, -tag or title:")
+	}
+	ids, err := parseTaskIDs(args[:split])
+	if err != nil {
+		return nil, setChanges{}, err
+	}
+	changes, err := parseSetModifiers(args[split:])
+	if err != nil {
+		return nil, setChanges{}, err
+	}
+	return ids, changes, nil
+}
+
+// report whether an arg starts the modifiers rather than naming a task
+func isSetModifier(arg string) bool {
+	if isTagModifier(arg) || hasTitleModifier(arg) {
+		return true
+	}
+	_, _, ok, _ := parseModifierToken(arg)
+	return ok
+}
+
+// report whether an arg adds or removes a tag
+func isTagModifier(arg string) bool {
+	return len(arg) > 1 && (arg[0] == '+' || arg[0] == '-')
+}
+
+// report whether an arg sets the title
+func hasTitleModifier(arg string) bool {
+	return len(arg) >= len(titleModifier) && strings.EqualFold(arg[:len(titleModifier)], titleModifier)
+}
+
+// parse pin set modifiers, reusing the creation grammar for pri, due and tags
+func parseSetModifiers(args []string) (setChanges, error) {
+	changes := setChanges{}
+	rest := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case hasTitleModifier(arg):
+			words := []string{}
+			if value := strings.TrimSpace(arg[len(titleModifier):]); value != "" {
+				words = append(words, value)
+			} else {
+				for i+1 < len(args) && !isSetModifier(args[i+1]) {
+					i++
+					words = append(words, args[i])
+				}
+			}
+			changes.title = strings.TrimSpace(strings.Join(words, " "))
+			if changes.title == "" {
+				return changes, fmt.Errorf("missing value for title")
+			}
+		case isTagModifier(arg) && arg[0] == '+':
+			changes.addTags = append(changes.addTags, arg[1:])
+		case isTagModifier(arg):
+			changes.removeTags = append(changes.removeTags, arg[1:])
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	words, mods, err := splitModifiers(rest)
+	if err != nil {
+		return changes, err
+	}
+	if len(words) > 0 {
+		return changes, fmt.Errorf("unexpected %q; quote values with spaces, e.g. by:\"next friday\"", strings.Join(words, " "))
+	}
+	for _, mod := range mods {
+		key, value, _ := strings.Cut(mod, ":")
+		if strings.EqualFold(strings.TrimSpace(value), "none") {
+			switch key {
+			case "pri":
+				none := 0
+				changes.priority = &none
+			case "due":
+				changes.due, changes.clearDue = nil, true
+			case "tags":
+				changes.tags, changes.replaceTags = nil, true
+			}
+			continue
+		}
+		opts, err := parseCreateModifiers([]string{mod})
+		if err != nil {
+			return changes, err
+		}
+		switch key {
+		case "pri":
+			if opts.priority < 0 {
+				return changes, fmt.Errorf("priority must be 0 or more, got %d", opts.priority)
+			}
+			changes.priority = &opts.priority
+		case "due":
+			changes.due, changes.clearDue = opts.due, false
+		case "tags":
+			changes.tags, changes.replaceTags = opts.tags, true
+		}
+	}
+	return changes, nil
+}
+
+// apply changes to each task, reporting errors per id
+func setTasks(ids []int, changes setChanges) {
+	err := withProjectLock(func() error {
+		for _, id := range ids {
+			changed, err := setTaskFields(id, changes)
+			if err != nil {
+				if errors.Is(err, config.ErrPunchlistNotFound) {
+					return err
+				}
+				fmt.Printf("Error updating task %d: %v\n", id, err)
+				continue
+			}
+			if len(changed) == 0 {
+				fmt.Printf("No changes to task %d.\n", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if printNotPunchlistError(err) {
+			return
+		}
+		fmt.Printf("Error updating tasks: %v\n", err)
+	}
+}
+
+// apply changes to one task, logging and reporting each changed field, and
+// return the log messages; a new title updates the heading and renames the
+// file to match
+func setTaskFields(id int, changes setChanges) ([]string, error) {
+	taskPath, err := findTaskFile(id)
+	if err != nil {
+		return nil, err
+	}
+	t, err := task.Parse(taskPath)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing task: %w", err)
+	}
+
+	messages := []string{}
+	retitled := changes.title != "" && changes.title != t.Title
+	if retitled {
+		messages = append(messages, setTaskTitle(t, changes.title))
+	}
+	if changes.priority != nil && *changes.priority != t.Priority {
+		messages = append(messages, priorityLogMessage(t.Priority, *changes.priority))
+		t.Priority = *changes.priority
+	}
+	if changes.clearDue && t.Due != nil {
+		messages = append(messages, dueLogMessage(t.Due, nil))
+		t.Due = nil
+	} else if changes.due != nil && (t.Due == nil || !t.Due.Equal(*changes.due)) {
+		messages = append(messages, dueLogMessage(t.Due, changes.due))
+		t.Due = changes.due
+	}
+	if tags := changedTags(t.Tags, changes); !sameTags(tags, t.Tags) {
+		messages = append(messages, fmt.Sprintf("tags changed from %s to %s", tagListText(t.Tags), tagListText(tags)))
+		t.Tags = tags
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	for _, msg := range messages {
+		t.Body = appendLogEntry(t.Body, msg, now)
+	}
+	t.UpdatedAt = now
+	if err := t.Write(taskPath); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Updated task %d: %s\n", id, strings.Join(messages, "; "))
+	if retitled {
+		renameRetitledTask(t, taskPath)
+	}
+	return messages, nil
+}
+
+// the tags a task ends up with after replacing, adding and removing
+func changedTags(current []string, changes setChanges) []string {
+	tags := current
+	if changes.replaceTags {
+		tags = changes.tags
+	}
+	result := []string{}
+	seen := map[string]bool{}
+	keep := func(tag string) {
+		if seen[tag] {
+			return
+		}
+		for _, removed := range changes.removeTags {
+			if tag == removed {
+				return
+			}
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	for _, tag := range tags {
+		keep(tag)
+	}
+	for _, tag := range changes.addTags {
+		keep(tag)
+	}
+	return result
+}
+
+// report whether two tag lists hold the same tags in the same order
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// render tags for the task log
+func tagListText(tags []string) string {
+	if len(tags) == 0 {
+		return "none"
+	}
+	return "{" + strings.Join(tags, ",") + "}"
+}
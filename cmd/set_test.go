@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"punchlist/task"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// test parsing ids and modifiers for pin set
+func TestParseSetArgs(t *testing.T) {
+	ids, changes, err := parseSetArgs([]string{"3", "5-6", "pri:1", "+urgent", "-later", "by", "next", "friday", "title:", "Ship", "it"})
+	if err != nil {
+		t.Fatalf("parseSetArgs failed: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int{3, 5, 6}) {
+		t.Errorf("Expected ids 3 5 6, got %v", ids)
+	}
+	if changes.priority == nil || *changes.priority != 1 || changes.due == nil || changes.title != "Ship it" {
+		t.Errorf("Expected priority, due and title, got %+v", changes)
+	}
+	if !reflect.DeepEqual(changes.addTags, []string{"urgent"}) || !reflect.DeepEqual(changes.removeTags, []string{"later"}) {
+		t.Errorf("Expected +urgent and -later, got %v %v", changes.addTags, changes.removeTags)
+	}
+
+	_, changes, err = parseSetArgs([]string{"3", "due:none", "pri:none", "tags:{a,b}"})
+	if err != nil {
+		t.Fatalf("parseSetArgs failed: %v", err)
+	}
+	if !changes.clearDue || changes.priority == nil || *changes.priority != 0 || !changes.replaceTags || len(changes.tags) != 2 {
+		t.Errorf("Expected cleared due and priority with replaced tags, got %+v", changes)
+	}
+
+	for _, args := range [][]string{
+		{"3", "4"},
+		{"pri:1"},
+		{"3", "pri:high"},
+		{"3", "by:next", "friday"},
+		{"3", "due:someday"},
+		{"3", "title:"},
+	} {
+		if _, _, err := parseSetArgs(args); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+	}
+}
+
+// test changing several tasks with pin set
+func TestSetCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug", "pri:2", "by:2026-03-01", "tags:{later,web}")
+	executeCommand("todo", "Plan offsite", "tags:{later}")
+
+	output, err := executeCommand("set", "1-2", "pri:1", "+urgent", "-later", "due:none")
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if !strings.Contains(output, "Updated task 1: priority changed from 2 to 1; due date cleared") ||
+		!strings.Contains(output, "Updated task 2: priority set to 1; tags changed from {later} to {urgent}") {
+		t.Errorf("Unexpected output:\n%s", output)
+	}
+
+	path, _ := findTaskFile(1)
+	first, err := task.Parse(path)
+	if err != nil {
+		t.Fatalf("Failed to parse task 1: %v", err)
+	}
+	if first.Priority != 1 || first.Due != nil || !reflect.DeepEqual(first.Tags, []string{"web", "urgent"}) {
+		t.Errorf("Expected priority 1, no due date and tags web,urgent, got %d %v %v", first.Priority, first.Due, first.Tags)
+	}
+	for _, expected := range []string{"priority changed from 2 to 1", "due date cleared (was 2026-03-01", "tags changed from {later,web} to {web,urgent}"} {
+		if !strings.Contains(first.Body, expected) {
+			t.Errorf("Expected log entry %q in body:\n%s", expected, first.Body)
+		}
+	}
+
+	// a new title renames the file, and repeating a change does nothing
+	output, _ = executeCommand("set", "2", "title:Plan team offsite", "pri:1")
+	if !strings.Contains(output, `Updated task 2: title changed from "Plan offsite" to "Plan team offsite"`) {
+		t.Errorf("Expected only the title to change, got %q", output)
+	}
+	if !strings.Contains(output, "Renamed 002-plan-offsite.md to 002-plan-team-offsite.md") {
+		t.Errorf("Expected the rename to be reported, got %q", output)
+	}
+	if path, _ := findTaskFile(2); filepath.Base(path) != "002-plan-team-offsite.md" {
+		t.Errorf("Expected the file to be renamed, got %s", path)
+	}
+	output, _ = executeCommand("set", "2", "pri:1")
+	if !strings.Contains(output, "No changes to task 2.") {
+		t.Errorf("Expected no changes, got %q", output)
+	}
+
+	output, _ = executeCommand("set", "9", "pri:1")
+	if !strings.Contains(output, "Error updating task 9") {
+		t.Errorf("Expected an error for a missing task, got %q", output)
+	}
+}
+
+// test that setting other fields leaves a hand-edited title's file name alone
+func TestSetKeepsFileNameWithoutTitle(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Alpha")
+	path, _ := findTaskFile(1)
+	edited, _ := task.Parse(path)
+	edited.Title = "Alpha renamed by hand"
+	edited.Write(path)
+
+	output, _ := executeCommand("set", "1", "pri:2")
+	if strings.Contains(output, "Renamed") {
+		t.Errorf("Expected no rename without title:, got %q", output)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected %s to keep its name: %v", filepath.Base(path), err)
+	}
+}
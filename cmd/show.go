@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"punchlist/task"
 	"strconv"
@@ -13,11 +14,22 @@ import (
 
 // create the show command
 func newShowCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "show [id]",
 		Short: "Show a task in detail",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
+			format, err := formatFlag(cmd)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			tmpl, err := templateFromFlags(cmd)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+
 			// parse id and load task
 			id, err := strconv.Atoi(args[0])
 			if err != nil {
@@ -40,6 +52,19 @@ func newShowCmd() *cobra.Command {
 				return
 			}
 
+			if tmpl != nil {
+				if err := writeTemplate(os.Stdout, tmpl, []*task.Task{t}, nil); err != nil {
+					fmt.Printf("Error writing task: %v\n", err)
+				}
+				return
+			}
+			if format != "text" {
+				if err := writeTaskDetail(os.Stdout, format, t); err != nil {
+					fmt.Printf("Error writing task: %v\n", err)
+				}
+				return
+			}
+
 			// print task details
 			fmt.Printf("ID: %d\n", t.ID)
 			fmt.Printf("Title: %s\n", t.Title)
@@ -53,12 +78,25 @@ func newShowCmd() *cobra.Command {
 			fmt.Printf("Completed: %s\n", formatOptionalTime(t.CompletedAt))
 			fmt.Printf("External refs: %s\n", formatList(t.ExternalRefs))
 			fmt.Printf("Path: %s\n", filepath.Clean(taskPath))
+			if len(t.Transitions) > 0 {
+				fmt.Println("History:")
+				for _, tr := range t.Transitions {
+					fmt.Printf("  %s %s -> %s", tr.At.Format(time.RFC3339), tr.From, tr.To)
+					if tr.Reason != "" {
+						fmt.Printf(" (%s)", tr.Reason)
+					}
+					fmt.Println()
+				}
+			}
 
 			if t.Body != "" {
 				fmt.Printf("\n-------\n%s\n", t.Body)
 			}
 		},
 	}
+	addFormatFlag(cmd)
+	addTemplateFlags(cmd)
+	return cmd
 }
 
 // render optional timestamps consistently
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"punchlist/config"
+	"punchlist/task"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// default ls ordering when neither flags nor config choose one
+const defaultSortSpec = "state"
+
+// one key in a --sort list, like due or -pri
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// sort key names and the aliases they accept
+var sortFields = map[string]string{
+	"state":        "state",
+	"id":           "id",
+	"pri":          "pri",
+	"priority":     "pri",
+	"due":          "due",
+	"created":      "created",
+	"created_at":   "created",
+	"updated":      "updated",
+	"updated_at":   "updated",
+	"started":      "started",
+	"started_at":   "started",
+	"completed":    "completed",
+	"completed_at": "completed",
+	"title":        "title",
+}
+
+// parse a comma separated sort spec such as "due,-pri,id"
+func parseSortKeys(spec string) ([]sortKey, error) {
+	keys := []sortKey{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		key := sortKey{}
+		switch part[0] {
+		case '-':
+			key.desc = true
+			part = part[1:]
+		case '+':
+			part = part[1:]
+		}
+		field, ok := sortFields[part]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort key %q (use state, id, pri, due, created, updated, started, completed, or title)", part)
+		}
+		key.field = field
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty sort spec %q", spec)
+	}
+	return keys, nil
+}
+
+// choose sort keys from --sort, then --order, then ls_sort in config
+func sortKeysFromFlags(cmd *cobra.Command) ([]sortKey, error) {
+	if cmd.Flags().Changed("sort") {
+		spec, _ := cmd.Flags().GetString("sort")
+		return parseSortKeys(spec)
+	}
+	if cmd.Flags().Changed("order") {
+		order, _ := cmd.Flags().GetString("order")
+		if strings.ToLower(strings.TrimSpace(order)) == "id" {
+			return parseSortKeys("id")
+		}
+		return parseSortKeys("state")
+	}
+	cfg, err := config.LoadConfig()
+	if err == nil && strings.TrimSpace(cfg.LsSort) != "" {
+		keys, err := parseSortKeys(cfg.LsSort)
+		if err != nil {
+			return nil, fmt.Errorf("ls_sort in config: %w", err)
+		}
+		return keys, nil
+	}
+	return parseSortKeys(defaultSortSpec)
+}
+
+// sort tasks by each key in turn, then by id; reverse flips the direction of
+// every key, so unset values still sort last
+func sortTasks(tasks []*task.Task, keys []sortKey, reverse bool) {
+	if reverse {
+		flipped := make([]sortKey, len(keys))
+		for i, key := range keys {
+			flipped[i] = sortKey{field: key.field, desc: !key.desc}
+		}
+		keys = flipped
+	}
+
+	var stateIndex map[string]int
+	for _, key := range keys {
+		if key.field == "state" {
+			stateIndex = buildStateOrderIndex(loadStateOrder())
+			break
+		}
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		for _, key := range keys {
+			if c := compareByKey(tasks[i], tasks[j], key, stateIndex); c != 0 {
+				return c < 0
+			}
+		}
+		if reverse {
+			return tasks[i].ID > tasks[j].ID
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+}
+
+// compare two tasks on one key; unset values sort last in either direction
+func compareByKey(a, b *task.Task, key sortKey, stateIndex map[string]int) int {
+	var c int
+	switch key.field {
+	case "state":
+		c = compareInts(stateIndex[stateOrderKey(a.State)], stateIndex[stateOrderKey(b.State)])
+	case "id":
+		c = compareInts(a.ID, b.ID)
+	case "pri":
+		// priority 0 means unset
+		if a.Priority == 0 || b.Priority == 0 {
+			return compareMissing(a.Priority == 0, b.Priority == 0)
+		}
+		c = compareInts(a.Priority, b.Priority)
+	case "title":
+		c = strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	case "created":
+		c = compareTimes(a.CreatedAt, b.CreatedAt)
+	case "updated":
+		c = compareTimes(a.UpdatedAt, b.UpdatedAt)
+	case "due":
+		return compareOptionalTimes(a.Due, b.Due, key.desc)
+	case "started":
+		return compareOptionalTimes(a.StartedAt, b.StartedAt, key.desc)
+	case "completed":
+		return compareOptionalTimes(a.CompletedAt, b.CompletedAt, key.desc)
+	}
+	if key.desc {
+		return -c
+	}
+	return c
+}
+
+// compare optional times, keeping unset ones last
+func compareOptionalTimes(a, b *time.Time, desc bool) int {
+	if a == nil || b == nil {
+		return compareMissing(a == nil, b == nil)
+	}
+	c := compareTimes(*a, *b)
+	if desc {
+		return -c
+	}
+	return c
+}
+
+// order a missing value after a present one
+func compareMissing(aMissing, bMissing bool) int {
+	switch {
+	case aMissing && bMissing:
+		return 0
+	case aMissing:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// compare ints for sorting
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compare times for sorting
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
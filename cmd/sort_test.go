@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"punchlist/config"
+	"punchlist/task"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test multi-key sorting with unset values last
+func TestSortTasks(t *testing.T) {
+	day := func(d int) *time.Time {
+		value := time.Date(2026, 1, d, 12, 0, 0, 0, time.UTC)
+		return &value
+	}
+	tasks := []*task.Task{
+		{ID: 1, Title: "b", Priority: 2},
+		{ID: 2, Title: "a", Priority: 1, Due: day(9)},
+		{ID: 3, Title: "C", Priority: 0, Due: day(3)},
+		{ID: 4, Title: "d", Priority: 3, Due: day(9)},
+	}
+	ids := func() string {
+		parts := []string{}
+		for _, t := range tasks {
+			parts = append(parts, string(rune('0'+t.ID)))
+		}
+		return strings.Join(parts, ",")
+	}
+
+	cases := []struct {
+		spec     string
+		reverse  bool
+		expected string
+	}{
+		{"due,-pri,id", false, "3,4,2,1"},
+		{"-due,pri", false, "2,4,3,1"},
+		{"pri", false, "2,1,4,3"},
+		{"-pri", false, "4,1,2,3"},
+		{"title", false, "2,1,3,4"},
+		{"id", true, "4,3,2,1"},
+		{"due", true, "4,2,3,1"},
+		{"pri,title", true, "4,1,2,3"},
+	}
+	for _, c := range cases {
+		keys, err := parseSortKeys(c.spec)
+		if err != nil {
+			t.Fatalf("parseSortKeys(%q): %v", c.spec, err)
+		}
+		sortTasks(tasks, keys, c.reverse)
+		if got := ids(); got != c.expected {
+			t.Errorf("sort %q reverse=%v: got %s, want %s", c.spec, c.reverse, got, c.expected)
+		}
+	}
+
+	if _, err := parseSortKeys("due,size"); err == nil || !strings.Contains(err.Error(), `unknown sort key "size"`) {
+		t.Errorf("Expected unknown sort key error, got %v", err)
+	}
+}
+
+// test --sort and the ls_sort config default
+func TestLsSort(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Later", "by:2026-03-01")
+	executeCommand("todo", "Undated", "pri:1")
+	executeCommand("todo", "Sooner", "by:2026-02-01")
+
+	output, _ := executeCommand("ls", "--sort", "due")
+	if !strings.Contains(output, "Sooner") || strings.Index(output, "Sooner") > strings.Index(output, "Later") || strings.Index(output, "Later") > strings.Index(output, "Undated") {
+		t.Errorf("Expected due order with undated last, got:\n%s", output)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.LsSort = "-pri,id"
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	output, _ = executeCommand("ls")
+	if !strings.HasPrefix(strings.TrimSpace(output), "2 TODO Undated") {
+		t.Errorf("Expected config sort to list the prioritized task first, got:\n%s", output)
+	}
+	if strings.Contains(output, stateSeparatorLine) {
+		t.Errorf("Expected no state separators when not sorted by state, got:\n%s", output)
+	}
+
+	output, _ = executeCommand("ls", "--order", "id")
+	if !strings.HasPrefix(strings.TrimSpace(output), "1 TODO Later") {
+		t.Errorf("Expected --order to override config sort, got:\n%s", output)
+	}
+}
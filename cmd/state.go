@@ -1,52 +1,19 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"punchlist/config"
 	"punchlist/task"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
-// locate a task file by id prefix
-func findTaskFile(id int) (string, error) {
-	tasksPath, err := tasksDir()
-	if err != nil {
-		return "", err
-	}
-	files, err := os.ReadDir(tasksPath)
-	if err != nil {
-		return "", err
-	}
-
-	for _, file := range files {
-		name := file.Name()
-		if !strings.HasSuffix(name, ".md") {
-			continue
-		}
-		prefix := strings.SplitN(name, "-", 2)[0]
-		if prefix == "" {
-			continue
-		}
-		parsedID, err := strconv.Atoi(prefix)
-		if err != nil {
-			continue
-		}
-		if parsedID == id {
-			return filepath.Join(tasksPath, name), nil
-		}
-	}
-
-	return "", fmt.Errorf("task with ID %d not found", id)
-}
-
 // create the start command
 func newStartCmd() *cobra.Command {
-	return &cobra.Command{
+	return addReasonFlag(&cobra.Command{
 		Use:     "start [id]",
 		Aliases: []string{"begun", "BEGUN", "START"},
 		Short:   "Start a task",
@@ -58,14 +25,14 @@ func newStartCmd() *cobra.Command {
 				fmt.Printf("Invalid task IDs: %v\n", err)
 				return
 			}
-			updateTaskState(ids, task.StateBegun)
+			updateTaskState(ids, task.StateBegun, reasonFlag(cmd))
 		},
-	}
+	})
 }
 
 // create the done command
 func newDoneCmd() *cobra.Command {
-	return &cobra.Command{
+	return addReasonFlag(&cobra.Command{
 		Use:     "done [id]",
 		Aliases: []string{"DONE"},
 		Short:   "Complete a task",
@@ -77,14 +44,14 @@ func newDoneCmd() *cobra.Command {
 				fmt.Printf("Invalid task IDs: %v\n", err)
 				return
 			}
-			updateTaskState(ids, task.StateDone)
+			updateTaskState(ids, task.StateDone, reasonFlag(cmd))
 		},
-	}
+	})
 }
 
 // create the notdo/defer command
 func newDeferCmd() *cobra.Command {
-	return &cobra.Command{
+	return addReasonFlag(&cobra.Command{
 		Use:     "notdo [id]",
 		Aliases: []string{"defer", "NOTDO"},
 		Short:   "Mark a task as not to do",
@@ -96,14 +63,14 @@ func newDeferCmd() *cobra.Command {
 				fmt.Printf("Invalid task IDs: %v\n", err)
 				return
 			}
-			updateTaskState(ids, task.StateNotDo)
+			updateTaskState(ids, task.StateNotDo, reasonFlag(cmd))
 		},
-	}
+	})
 }
 
 // create the block command
 func newBlockCmd() *cobra.Command {
-	return &cobra.Command{
+	return addReasonFlag(&cobra.Command{
 		Use:     "block [id]",
 		Aliases: []string{"BLOCK"},
 		Short:   "Change a task's status to BLOCKED",
@@ -115,14 +82,14 @@ func newBlockCmd() *cobra.Command {
 				fmt.Printf("Invalid task IDs: %v\n", err)
 				return
 			}
-			updateTaskState(ids, task.StateBlock)
+			updateTaskState(ids, task.StateBlock, reasonFlag(cmd))
 		},
-	}
+	})
 }
 
 // create the confirm command
 func newConfirmCmd() *cobra.Command {
-	return &cobra.Command{
+	return addReasonFlag(&cobra.Command{
 		Use:     "confirm [id]",
 		Aliases: []string{"CONFIRM"},
 		Short:   "Mark a task as needing confirmation",
@@ -134,25 +101,63 @@ func newConfirmCmd() *cobra.Command {
 				fmt.Printf("Invalid task IDs: %v\n", err)
 				return
 			}
-			updateTaskState(ids, task.StateConfirm)
+			updateTaskState(ids, task.StateConfirm, reasonFlag(cmd))
 		},
-	}
+	})
 }
 
-// update multiple tasks to a new state
-func updateTaskState(ids []int, newState task.State) {
-	for _, id := range ids {
-		if err := updateTaskStateSingle(id, newState); err != nil {
-			if printNotPunchlistError(err) {
+// create the generic move command for any state, including custom ones
+func newMoveCmd() *cobra.Command {
+	return addReasonFlag(&cobra.Command{
+		Use:               "move [ids] [state]",
+		Aliases:           []string{"mv"},
+		Short:             "Move tasks to any state",
+		Long:              `Move tasks to any state, including custom states declared in .punchlist/config.yaml. Transitions not allowed by the config are rejected.`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: moveArgCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			stateArg := args[len(args)-1]
+			newState, ok := task.ParseState(stateArg)
+			if !ok {
+				fmt.Printf("Unknown state: %s (known states: %s)\n", stateArg, strings.Join(stateCompletionCandidates(), ", "))
 				return
 			}
-			fmt.Printf("Error updating task %d: %v\n", id, err)
+			// parse one or many ids
+			ids, err := parseTaskIDs(args[:len(args)-1])
+			if err != nil {
+				fmt.Printf("Invalid task IDs: %v\n", err)
+				return
+			}
+			updateTaskState(ids, newState, reasonFlag(cmd))
+		},
+	})
+}
+
+// update multiple tasks to a new state
+func updateTaskState(ids []int, newState task.State, reason string) {
+	err := withProjectLock(func() error {
+		for _, id := range ids {
+			if err := updateTaskStateSingle(id, newState, reason); err != nil {
+				if errors.Is(err, config.ErrPunchlistNotFound) {
+					return err
+				}
+				fmt.Printf("Error updating task %d: %v\n", id, err)
+				continue
+			}
+			fmt.Printf("Task %d moved to %s\n", id, newState)
+		}
+		return nil
+	})
+	if err != nil {
+		if printNotPunchlistError(err) {
+			return
 		}
+		fmt.Printf("Error updating tasks: %v\n", err)
 	}
 }
 
-// update a single task's state and timestamps
-func updateTaskStateSingle(id int, newState task.State) error {
+// update a single task's state and timestamps, recording the transition
+func updateTaskStateSingle(id int, newState task.State, reason string) error {
 	taskPath, err := findTaskFile(id)
 	if err != nil {
 		return err
@@ -163,20 +168,42 @@ func updateTaskStateSingle(id int, newState task.State) error {
 		return err
 	}
 
-	t.State = newState
-	t.UpdatedAt = time.Now()
+	if err := task.ActiveWorkflow().CheckTransition(t.State, newState); err != nil {
+		return fmt.Errorf("%w (see transitions in %s/config.yaml)", err, config.PunchlistDir)
+	}
+
+	now := time.Now()
+	previous := t.State
+	if t.RecordTransition(newState, reason, now) {
+		t.Body = appendLogEntry(t.Body, transitionLogMessage(previous, newState, reason), now)
+	}
+	t.UpdatedAt = now
 	if newState == task.StateBegun {
-		now := time.Now()
 		t.StartedAt = &now
 	} else if newState == task.StateDone {
-		now := time.Now()
 		t.CompletedAt = &now
 	}
 
-	if err := t.Write(taskPath); err != nil {
-		return err
+	return t.Write(taskPath)
+}
+
+// describe a state change for the task log
+func transitionLogMessage(from, to task.State, reason string) string {
+	msg := fmt.Sprintf("state changed from %s to %s", from, to)
+	if reason != "" {
+		msg += ": " + reason
 	}
+	return msg
+}
+
+// add the --reason flag shared by state commands
+func addReasonFlag(cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().StringP("reason", "r", "", "Why the state is changing, recorded in the task history")
+	return cmd
+}
 
-	fmt.Printf("Task %d moved to %s\n", id, newState)
-	return nil
+// read the --reason flag
+func reasonFlag(cmd *cobra.Command) string {
+	reason, _ := cmd.Flags().GetString("reason")
+	return strings.TrimSpace(reason)
 }
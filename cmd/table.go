@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"punchlist/task"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// columns ls shows as a table when none are chosen
+var defaultTableColumns = []string{"id", "state", "title", "priority", "due", "tags"}
+
+// space between table columns
+const tableGap = "  "
+
+// narrowest a title gets before other columns are dropped
+const minTitleWidth = 12
+
+// how a task table is laid out
+type tableOptions struct {
+	// width to fit, or 0 for no limit
+	width int
+	color bool
+	// wrap long titles onto more lines instead of truncating them
+	wrap bool
+	// print a separator line where the state changes
+	groupByState bool
+	// project alias per task for the project column
+	projectOf map[*task.Task]string
+	now       time.Time
+}
+
+// write tasks as aligned columns under a header, fitting the width by
+// shrinking the title and then dropping columns from the right
+func renderTable(w io.Writer, tasks []*task.Task, columns []string, opts tableOptions) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	if opts.now.IsZero() {
+		opts.now = time.Now()
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(strings.TrimSuffix(column, "_at"))
+	}
+	rows := make([][]string, len(tasks))
+	for r, t := range tasks {
+		rows[r] = make([]string, len(columns))
+		for i, column := range columns {
+			if column == "project" {
+				rows[r][i] = opts.projectOf[t]
+				continue
+			}
+			rows[r][i] = textColumnValue(t, column)
+		}
+	}
+
+	widths := make([]int, len(columns))
+	for i := range columns {
+		widths[i] = utf8.RuneCountInString(header[i])
+		for _, row := range rows {
+			if n := utf8.RuneCountInString(row[i]); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	visible := fitColumns(columns, widths, opts.width)
+
+	tableWidth := 0
+	for n, i := range visible {
+		if n > 0 {
+			tableWidth += len(tableGap)
+		}
+		tableWidth += widths[i]
+	}
+
+	headerCells := make([]string, len(columns))
+	for i := range columns {
+		headerCells[i] = paint(header[i], opts.color, "bold")
+	}
+	if err := writeTableLine(w, columns, visible, widths, header, headerCells); err != nil {
+		return err
+	}
+
+	var lastState task.State
+	for r, t := range tasks {
+		if opts.groupByState && r > 0 && t.State != lastState {
+			line := strings.Repeat("-", tableWidth)
+			if _, err := fmt.Fprintln(w, paint(line, opts.color, "dim")); err != nil {
+				return err
+			}
+		}
+		lastState = t.State
+
+		// split cells into lines; only the title wraps
+		lines := [][]string{rows[r]}
+		for _, i := range visible {
+			text := rows[r][i]
+			if utf8.RuneCountInString(text) <= widths[i] {
+				continue
+			}
+			if !opts.wrap || columns[i] != "title" {
+				lines[0][i] = truncateText(widths[i], text)
+				continue
+			}
+			for n, part := range wrapText(text, widths[i]) {
+				if n == 0 {
+					lines[0][i] = part
+					continue
+				}
+				if n >= len(lines) {
+					lines = append(lines, make([]string, len(columns)))
+				}
+				lines[n][i] = part
+			}
+		}
+		for _, line := range lines {
+			styled := make([]string, len(columns))
+			for i, column := range columns {
+				styled[i] = paint(line[i], opts.color, cellStyles(t, column, opts.now)...)
+			}
+			if err := writeTableLine(w, columns, visible, widths, line, styled); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// choose which columns fit in width, shrinking the title first
+func fitColumns(columns []string, widths []int, width int) []int {
+	visible := make([]int, len(columns))
+	for i := range columns {
+		visible[i] = i
+	}
+	if width <= 0 {
+		return visible
+	}
+	total := func() int {
+		sum := len(tableGap) * (len(visible) - 1)
+		for _, i := range visible {
+			sum += widths[i]
+		}
+		return sum
+	}
+
+	title := -1
+	for i, column := range columns {
+		if column == "title" {
+			title = i
+		}
+	}
+	if over := total() - width; over > 0 && title >= 0 {
+		shrunk := widths[title] - over
+		if shrunk < minTitleWidth {
+			shrunk = minTitleWidth
+		}
+		if shrunk < widths[title] {
+			widths[title] = shrunk
+		}
+	}
+	// drop columns from the right, keeping id and title
+	for total() > width && len(visible) > 1 {
+		dropped := false
+		for n := len(visible) - 1; n >= 0; n-- {
+			if column := columns[visible[n]]; column != "id" && column != "title" {
+				visible = append(visible[:n], visible[n+1:]...)
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			break
+		}
+	}
+	return visible
+}
+
+// write one line of cells, padding plain text so colors don't skew widths
+func writeTableLine(w io.Writer, columns []string, visible []int, widths []int, plain, styled []string) error {
+	var b strings.Builder
+	for n, i := range visible {
+		if n > 0 {
+			b.WriteString(tableGap)
+		}
+		gap := widths[i] - utf8.RuneCountInString(plain[i])
+		if gap < 0 {
+			gap = 0
+		}
+		switch {
+		case columns[i] == "id":
+			b.WriteString(strings.Repeat(" ", gap))
+			b.WriteString(styled[i])
+		case n == len(visible)-1:
+			b.WriteString(styled[i])
+		default:
+			b.WriteString(styled[i])
+			b.WriteString(strings.Repeat(" ", gap))
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+	return err
+}
+
+// colors for one cell of a task row
+func cellStyles(t *task.Task, column string, now time.Time) []string {
+	closed := task.ActiveWorkflow().IsClosed(t.State)
+	switch column {
+	case "state":
+		return stateStyles(t.State)
+	case "priority":
+		if closed {
+			return []string{"dim"}
+		}
+		return priorityStyles(t.Priority)
+	case "due":
+		if t.Due == nil {
+			return nil
+		}
+		return dueStyles(daysUntil(*t.Due, now), closed)
+	case "title":
+		if closed {
+			return []string{"dim"}
+		}
+	}
+	return nil
+}
+
+// break text into lines of at most width runes, at spaces where possible
+func wrapText(text string, width int) []string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"punchlist/task"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sample tasks for table tests
+func tableTasks(now time.Time) []*task.Task {
+	overdue := now.AddDate(0, 0, -2)
+	return []*task.Task{
+		{ID: 3, Title: "Short", State: task.StateBegun, Priority: 1, Due: &overdue, Tags: []string{"a"}},
+		{ID: 12, Title: "A much longer title that needs to be shortened", State: task.StateTodo},
+	}
+}
+
+// test fitting, truncating and wrapping tables
+func TestRenderTable(t *testing.T) {
+	now := time.Date(2026, 4, 1, 9, 0, 0, 0, time.Local)
+	columns := []string{"id", "state", "title", "priority", "due"}
+	due := now.AddDate(0, 0, -2).Format("2006-01-02")
+
+	var out bytes.Buffer
+	renderTable(&out, tableTasks(now), columns, tableOptions{now: now})
+	expected := "ID  STATE  TITLE                                           PRIORITY  DUE\n" +
+		" 3  BEGUN  Short                                           1         " + due + "\n" +
+		"12  TODO   A much longer title that needs to be shortened  -         -\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected unlimited table:\n%s\nwant\n%s", out.String(), expected)
+	}
+
+	out.Reset()
+	renderTable(&out, tableTasks(now), columns, tableOptions{width: 50, now: now})
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if n := len([]rune(line)); n > 50 {
+			t.Errorf("Line is %d wide, want at most 50: %q", n, line)
+		}
+	}
+	if !strings.Contains(out.String(), "A much longer ti…") {
+		t.Errorf("Expected a truncated title:\n%s", out.String())
+	}
+
+	out.Reset()
+	renderTable(&out, tableTasks(now), columns, tableOptions{width: 50, wrap: true, now: now})
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 5 || strings.TrimSpace(lines[3]) != "title that needs" {
+		t.Errorf("Expected the title to wrap onto more lines:\n%s", out.String())
+	}
+
+	out.Reset()
+	renderTable(&out, tableTasks(now), columns, tableOptions{width: 30, now: now})
+	if strings.Contains(out.String(), "DUE") || !strings.Contains(out.String(), "TITLE") {
+		t.Errorf("Expected trailing columns to be dropped:\n%s", out.String())
+	}
+
+	out.Reset()
+	renderTable(&out, tableTasks(now), columns, tableOptions{color: true, now: now})
+	for _, expected := range []string{"\x1b[33mBEGUN\x1b[0m", "\x1b[1;31m1\x1b[0m", "\x1b[1;31m" + due + "\x1b[0m"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in colored table:\n%q", expected, out.String())
+		}
+	}
+}
+
+// test --color modes and NO_COLOR
+func TestColorMode(t *testing.T) {
+	defer setColorMode(colorAuto)
+
+	t.Setenv("NO_COLOR", "1")
+	setColorMode(colorAuto)
+	if colorEnabled() {
+		t.Errorf("Expected NO_COLOR to turn colors off")
+	}
+	setColorMode(colorAlways)
+	if !colorEnabled() {
+		t.Errorf("Expected --color=always to win over NO_COLOR")
+	}
+	setColorMode(colorNever)
+	if colorEnabled() {
+		t.Errorf("Expected --color=never to turn colors off")
+	}
+	if err := setColorMode("sometimes"); err == nil {
+		t.Errorf("Expected an error for an unknown mode")
+	}
+}
+
+// test colored plain output when forced on a pipe
+func TestLsColorFlag(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	defer setColorMode(colorAuto)
+
+	executeCommand("init")
+	executeCommand("todo", "Colored", "pri:1")
+	output, _ := executeCommand("ls", "--color", "always")
+	if !strings.Contains(output, "\x1b[36mTODO\x1b[0m") || !strings.Contains(output, "\x1b[1;31mpri:1\x1b[0m") {
+		t.Errorf("Expected colored output, got %q", output)
+	}
+	output, _ = executeCommand("ls")
+	if strings.Contains(output, "\x1b[") {
+		t.Errorf("Expected plain output on a pipe, got %q", output)
+	}
+}
+
+// test wrapping words to a width
+func TestWrapText(t *testing.T) {
+	got := wrapText("one two three fourfivesix", 8)
+	expected := []string{"one two", "three", "fourfive", "six"}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("wrapText = %q, want %q", got, expected)
+	}
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"punchlist/task"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// file extension for named templates in .punchlist/templates
+const templateExt = ".tmpl"
+
+// add the --template and --template-name flags
+func addTemplateFlags(cmd *cobra.Command) {
+	cmd.Flags().String("template", "", "Render each task with a Go template")
+	cmd.Flags().String("template-name", "", "Render with a named template from .punchlist/templates")
+	_ = cmd.RegisterFlagCompletionFunc("template-name", templateNameCompletion)
+}
+
+// build the template selected by --template or --template-name, or nil for neither
+func templateFromFlags(cmd *cobra.Command) (*template.Template, error) {
+	text, _ := cmd.Flags().GetString("template")
+	name, _ := cmd.Flags().GetString("template-name")
+	if text != "" && name != "" {
+		return nil, fmt.Errorf("use either --template or --template-name, not both")
+	}
+	if (text != "" || name != "") && cmd.Flags().Changed("format") {
+		return nil, fmt.Errorf("use either --format or a template, not both")
+	}
+	if text != "" {
+		return parseTaskTemplate("inline", text)
+	}
+	if name != "" {
+		return loadNamedTemplate(name)
+	}
+	return nil, nil
+}
+
+// parse template text with the helper functions
+func parseTaskTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs(time.Now())).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// load a named template, with the other files in the folder available to {{template}}
+func loadNamedTemplate(name string) (*template.Template, error) {
+	dir, err := templatesDir()
+	if err != nil {
+		return nil, err
+	}
+	names, err := templateNames(dir, templateExt)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSuffix(name, templateExt)
+	found := false
+	for _, candidate := range names {
+		if candidate == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		if len(names) == 0 {
+			return nil, fmt.Errorf("template %q not found: no templates in %s", name, dir)
+		}
+		return nil, fmt.Errorf("template %q not found in %s (have %s)", name, dir, strings.Join(names, ", "))
+	}
+
+	root := template.New(name).Funcs(templateFuncs(time.Now()))
+	for _, candidate := range names {
+		content, err := os.ReadFile(filepath.Join(dir, candidate+templateExt))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := root.New(candidate).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("invalid template %s: %w", candidate+templateExt, err)
+		}
+	}
+	return root.Lookup(name), nil
+}
+
+// list names of files with extension ext in a folder, without the extension
+func templateNames(dir, ext string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ext) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), ext))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// complete --template-name with the project's template names
+func templateNameCompletion(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	dir, err := templatesDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, _ := templateNames(dir, templateExt)
+	filtered := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) {
+			filtered = append(filtered, name)
+		}
+	}
+	return stringsToCompletions(filtered), cobra.ShellCompDirectiveNoFileComp
+}
+
+// a task as templates see it, with the alias of its project when listing
+// several
+type templateTask struct {
+	*task.Task
+	Project string
+}
+
+// render each task, ending every rendering with a newline; projectOf
+// gives .Project for tasks gathered from several projects
+func writeTemplate(w io.Writer, tmpl *template.Template, tasks []*task.Task, projectOf map[*task.Task]string) error {
+	var buf bytes.Buffer
+	for _, t := range tasks {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, templateTask{Task: t, Project: projectOf[t]}); err != nil {
+			return fmt.Errorf("rendering task %d: %w", t.ID, err)
+		}
+		if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// helper functions available to templates
+func templateFuncs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"trunc": truncateText,
+		"pad": func(width int, value any) string {
+			return padText(fmt.Sprint(displayValue(value)), width, false)
+		},
+		"padl": func(width int, value any) string {
+			return padText(fmt.Sprint(displayValue(value)), width, true)
+		},
+		"join": func(sep string, items []string) string {
+			return strings.Join(items, sep)
+		},
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"default": defaultValue,
+		"date": func(layout string, value any) string {
+			t, ok := timeArg(value)
+			if !ok {
+				return ""
+			}
+			return t.Format(layout)
+		},
+		"iso": func(value any) string {
+			t, ok := timeArg(value)
+			if !ok {
+				return ""
+			}
+			return t.Format(time.RFC3339)
+		},
+		"rel": func(value any) string {
+			t, ok := timeArg(value)
+			if !ok {
+				return ""
+			}
+			return relativeDay(t, now)
+		},
+		"color": colorText,
+		"bold": func(value any) string {
+			return colorText("bold", value)
+		},
+	}
+}
+
+// shorten text to a width in runes, marking the cut with an ellipsis
+func truncateText(width int, value any) string {
+	text := fmt.Sprint(displayValue(value))
+	if width <= 0 || utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	if width == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// pad text to a width in runes, on the left when right-aligning
+func padText(text string, width int, right bool) string {
+	gap := width - utf8.RuneCountInString(text)
+	if gap <= 0 {
+		return text
+	}
+	if right {
+		return strings.Repeat(" ", gap) + text
+	}
+	return text + strings.Repeat(" ", gap)
+}
+
+// fall back to a default for empty or nil values
+func defaultValue(fallback string, value any) any {
+	value = displayValue(value)
+	if value == nil || fmt.Sprint(value) == "" || fmt.Sprint(value) == "0" {
+		return fallback
+	}
+	return value
+}
+
+// unwrap optional values so nil pointers print as empty
+func displayValue(value any) any {
+	switch v := value.(type) {
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return value
+}
+
+// accept time.Time or *time.Time from templates
+func timeArg(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, false
+		}
+		return *v, true
+	}
+	return time.Time{}, false
+}
+
+// wrap text in a named color when colors are on
+func colorText(name string, value any) string {
+	return paint(fmt.Sprint(displayValue(value)), colorEnabled(), strings.ToLower(name))
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test template helpers that don't need a project
+func TestTemplateHelpers(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	cases := map[int]string{
+		0:   "today",
+		1:   "tomorrow",
+		-1:  "yesterday",
+		3:   "in 3d",
+		-3:  "3d ago",
+		21:  "in 3w",
+		-90: "3mo ago",
+	}
+	for days, expected := range cases {
+		due := time.Date(2026, 3, 10+days, 12, 0, 0, 0, time.Local)
+		if got := relativeDay(due, now); got != expected {
+			t.Errorf("relativeDay(%+d days) = %q, want %q", days, got, expected)
+		}
+	}
+
+	if got := truncateText(5, "Release notes"); got != "Rele…" {
+		t.Errorf("Unexpected truncation: %q", got)
+	}
+	if got := truncateText(40, "short"); got != "short" {
+		t.Errorf("Unexpected truncation of short text: %q", got)
+	}
+	if got := padText("7", 3, true); got != "  7" {
+		t.Errorf("Unexpected left padding: %q", got)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if got := colorText("red", "x"); got != "x" {
+		t.Errorf("Expected NO_COLOR to disable color, got %q", got)
+	}
+}
+
+// test --template and --template-name rendering for ls and show
+func TestTemplateOutput(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Write the release announcement", "pri:1", "tags:{launch,web}")
+	executeCommand("todo", "Tidy up")
+
+	output, _ := executeCommand("ls", "--order", "id", "--template", `{{.ID | padl 3}} {{.Title | trunc 10}} [{{.Tags | join "+"}}] {{.Due | rel | default "none"}}`)
+	expected := "  1 Write the… [launch+web] none\n  2 Tidy up [] none\n"
+	if output != expected {
+		t.Errorf("Unexpected template output:\n%q\nwant\n%q", output, expected)
+	}
+
+	root, _ := config.FindPunchlistRoot()
+	templates := filepath.Join(root, config.PunchlistDir, "templates")
+	if err := os.MkdirAll(templates, 0755); err != nil {
+		t.Fatalf("Failed to create templates dir: %v", err)
+	}
+	os.WriteFile(filepath.Join(templates, "parts.tmpl"), []byte(`{{define "badge"}}<{{.State}}>{{end}}`), 0644)
+	os.WriteFile(filepath.Join(templates, "card.tmpl"), []byte("{{template \"badge\" .}} {{.Title}}\n{{.Body}}\n"), 0644)
+
+	output, _ = executeCommand("show", "2", "--template-name", "card")
+	if !strings.HasPrefix(output, "<TODO> Tidy up\n# Tidy up") {
+		t.Errorf("Unexpected named template output: %q", output)
+	}
+
+	output, _ = executeCommand("ls", "--template-name", "missing")
+	if !strings.Contains(output, `template "missing" not found`) || !strings.Contains(output, "card, parts") {
+		t.Errorf("Expected missing template error listing names, got %q", output)
+	}
+
+	output, _ = executeCommand("ls", "--template", "{{.Nope}}")
+	if !strings.Contains(output, "Error listing tasks") {
+		t.Errorf("Expected error for unknown field, got %q", output)
+	}
+}
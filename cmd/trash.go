@@ -0,0 +1,461 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/fsutil"
+	"punchlist/task"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// folder inside .trash with a sidecar file per trashed task
+const trashMetaDirName = ".meta"
+
+// what pin del records about a task it moves to the trash
+type trashMeta struct {
+	ID        int       `yaml:"id"`
+	Title     string    `yaml:"title,omitempty"`
+	DeletedAt time.Time `yaml:"deleted_at"`
+	// relative to the project root, with forward slashes
+	OriginalPath string `yaml:"original_path"`
+}
+
+// one task file in the trash, with its sidecar when pin del wrote one
+type trashEntry struct {
+	path     string
+	task     *task.Task
+	parseErr error
+	meta     *trashMeta
+}
+
+// create the trash command and its subcommands
+func newTrashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List and empty deleted tasks",
+		Long: `List the tasks pin del moved to .trash/, newest first, with the id each had
+and when it was deleted. Put one back with pin restore, or remove old ones for
+good with pin trash purge:
+  pin trash
+  pin restore 12
+  pin trash purge --older-than 30d`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runTrashList()
+		},
+	}
+
+	list := &cobra.Command{
+		Use:   "ls",
+		Short: "List deleted tasks",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runTrashList()
+		},
+	}
+
+	purge := &cobra.Command{
+		Use:   "purge",
+		Short: "Delete trashed tasks for good",
+		Long: `Delete every task in the trash, or with --older-than only those deleted at
+least that long ago, such as 30d, 2w or 12h. Tasks trashed before deletion
+times were recorded are only removed without --older-than. pin undo can bring
+a purge back.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			olderThan, _ := cmd.Flags().GetString("older-than")
+			var age time.Duration
+			if olderThan != "" {
+				var err error
+				if age, err = parseAge(olderThan); err != nil {
+					fmt.Printf("Invalid --older-than: %v\n", err)
+					return
+				}
+			}
+			if err := withProjectLock(func() error { return purgeTrash(olderThan != "", age, time.Now()) }); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error purging trash: %v\n", err)
+			}
+		},
+	}
+	purge.Flags().String("older-than", "", "Only purge tasks deleted at least this long ago, e.g. 30d")
+
+	cmd.AddCommand(list, purge)
+	return cmd
+}
+
+// create the restore command
+func newRestoreCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <id|file>",
+		Short: "Put a deleted task back",
+		Long: `Move a task from .trash/ back to where it was, by the id it had or its file
+name in pin trash. If another task has taken its id since, the restored task
+gets the next free id and a log entry noting the old one.`,
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return trashCompletions(toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := withProjectLock(func() error { return restoreTask(args[0]) }); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error restoring task: %v\n", err)
+			}
+		},
+	}
+}
+
+// print the trash, reporting errors like other commands
+func runTrashList() {
+	if err := listTrash(); err != nil {
+		if printNotPunchlistError(err) {
+			return
+		}
+		fmt.Printf("Error listing trash: %v\n", err)
+	}
+}
+
+// path of the sidecar for a file in the trash
+func trashMetaPath(trashPath string) string {
+	name := strings.TrimSuffix(filepath.Base(trashPath), filepath.Ext(trashPath)) + ".yaml"
+	return filepath.Join(filepath.Dir(trashPath), trashMetaDirName, name)
+}
+
+// record where a trashed task came from and when it was deleted
+func writeTrashMeta(trashPath string, meta trashMeta) error {
+	root, err := punchlistRoot()
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(root, meta.OriginalPath); err == nil {
+		meta.OriginalPath = filepath.ToSlash(rel)
+	}
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	metaPath := trashMetaPath(trashPath)
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFile(metaPath, data, 0644)
+}
+
+// remove the sidecar for a file leaving the trash, and the records folder
+// once it's empty
+func removeTrashMeta(trashPath string) error {
+	metaPath := trashMetaPath(trashPath)
+	if err := fsutil.Remove(metaPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// fails harmlessly while other records remain
+	os.Remove(filepath.Dir(metaPath))
+	return nil
+}
+
+// load every task in the trash with its sidecar, newest deletion first and
+// tasks without a recorded deletion time last
+func loadTrash() ([]*trashEntry, error) {
+	trashPath, err := trashDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(trashPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*trashEntry{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".md") || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		entry := &trashEntry{path: filepath.Join(trashPath, file.Name())}
+		entry.task, entry.parseErr = task.Parse(entry.path)
+		if data, err := os.ReadFile(trashMetaPath(entry.path)); err == nil {
+			meta := &trashMeta{}
+			if yaml.Unmarshal(data, meta) == nil {
+				entry.meta = meta
+			}
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].meta, entries[j].meta
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.DeletedAt.After(b.DeletedAt)
+	})
+	return entries, nil
+}
+
+// the id a trashed task had when it was deleted
+func (e *trashEntry) id() int {
+	if e.meta != nil {
+		return e.meta.ID
+	}
+	if e.task != nil {
+		return e.task.ID
+	}
+	return 0
+}
+
+// the title of a trashed task
+func (e *trashEntry) title() string {
+	if e.task != nil && e.task.Title != "" {
+		return e.task.Title
+	}
+	if e.meta != nil {
+		return e.meta.Title
+	}
+	return ""
+}
+
+// when a trashed task was deleted, for display
+func (e *trashEntry) deleted() string {
+	if e.meta == nil {
+		return "unknown"
+	}
+	return e.meta.DeletedAt.Local().Format("2006-01-02 15:04")
+}
+
+// print each trashed task with its original id and deletion time
+func listTrash() error {
+	entries, err := loadTrash()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("Trash is empty.")
+		return nil
+	}
+
+	rows := [][]string{{"ID", "DELETED", "TITLE", "FILE"}}
+	for _, entry := range entries {
+		rows = append(rows, []string{strconv.Itoa(entry.id()), entry.deleted(), entry.title(), filepath.Base(entry.path)})
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	color := colorEnabled()
+	for n, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = padText(cell, widths[i], i == 0)
+		}
+		line := strings.TrimRight(strings.Join(cells, tableGap), " ")
+		if n == 0 {
+			line = paint(line, color, "bold")
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// find the trashed task an argument names, by original id or file name
+func findTrashEntry(arg string) (*trashEntry, error) {
+	entries, err := loadTrash()
+	if err != nil {
+		return nil, err
+	}
+	id, byID := 0, false
+	if n, err := strconv.Atoi(arg); err == nil {
+		id, byID = n, true
+	}
+	matches := []*trashEntry{}
+	for _, entry := range entries {
+		name := filepath.Base(entry.path)
+		if (byID && entry.id() == id) || name == arg || name == arg+".md" {
+			matches = append(matches, entry)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no task %s in the trash; pin trash lists them", arg)
+	case 1:
+		return matches[0], nil
+	}
+	names := []string{}
+	for _, entry := range matches {
+		names = append(names, fmt.Sprintf("%s (deleted %s)", filepath.Base(entry.path), entry.deleted()))
+	}
+	return nil, fmt.Errorf("more than one trashed task had id %s: %s; restore one by file name", arg, strings.Join(names, ", "))
+}
+
+// move a trashed task back where it was, giving it a new id if its old one
+// has been taken since
+func restoreTask(arg string) error {
+	entry, err := findTrashEntry(arg)
+	if err != nil {
+		return err
+	}
+	if entry.parseErr != nil {
+		return fmt.Errorf("can't restore %s: %w", filepath.Base(entry.path), entry.parseErr)
+	}
+	root, err := punchlistRoot()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	tasksPath, err := tasksDir()
+	if err != nil {
+		return err
+	}
+	idx, err := loadTaskIndex(tasksPath)
+	if err != nil {
+		return err
+	}
+
+	t := entry.task
+	oldID := t.ID
+	target := ""
+	if entry.meta != nil && entry.meta.OriginalPath != "" {
+		target = filepath.Join(root, filepath.FromSlash(entry.meta.OriginalPath))
+	}
+	if t.ID <= 0 || idx.usedIDs()[t.ID] {
+		if t.ID, err = allocateTaskID(cfg, tasksPath); err != nil {
+			return err
+		}
+		target = ""
+	}
+	if target == "" {
+		target = filepath.Join(tasksPath, fmt.Sprintf("%0*d-%s.md", idWidthFromConfig(cfg), t.ID, slugify(t.Title)))
+	}
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("can't restore %s: %s already exists", filepath.Base(entry.path), target)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	if t.ID == oldID {
+		// the id is still free, so the file goes back exactly as it was
+		if err := fsutil.Rename(entry.path, target); err != nil {
+			return err
+		}
+		fmt.Printf("Restored task %d: %s\n", t.ID, target)
+	} else {
+		now := time.Now()
+		t.Body = appendLogEntry(t.Body, fmt.Sprintf("restored from trash as task %d (task %d was taken)", t.ID, oldID), now)
+		t.UpdatedAt = now
+		if err := t.Write(target); err != nil {
+			return err
+		}
+		if err := fsutil.Remove(entry.path); err != nil {
+			return err
+		}
+		fmt.Printf("Restored task %d as task %d: %s\n", oldID, t.ID, target)
+	}
+	if err := removeTrashMeta(entry.path); err != nil {
+		return err
+	}
+
+	if t.ID >= cfg.NextID {
+		cfg.NextID = t.ID + 1
+		if err := config.SaveConfig(cfg); err != nil {
+			return fmt.Errorf("error saving config: %w", err)
+		}
+	}
+	return nil
+}
+
+// delete trashed tasks for good, only those deleted at least age before now
+// when byAge is set
+func purgeTrash(byAge bool, age time.Duration, now time.Time) error {
+	entries, err := loadTrash()
+	if err != nil {
+		return err
+	}
+	purged, unknown := 0, 0
+	for _, entry := range entries {
+		if byAge {
+			if entry.meta == nil {
+				unknown++
+				continue
+			}
+			if now.Sub(entry.meta.DeletedAt) < age {
+				continue
+			}
+		}
+		if err := fsutil.Remove(entry.path); err != nil {
+			return err
+		}
+		if err := removeTrashMeta(entry.path); err != nil {
+			return err
+		}
+		purged++
+	}
+
+	switch purged {
+	case 0:
+		fmt.Println("Nothing to purge.")
+	case 1:
+		fmt.Println("Purged 1 task from the trash.")
+	default:
+		fmt.Printf("Purged %d tasks from the trash.\n", purged)
+	}
+	if unknown > 0 {
+		fmt.Printf("Kept %d without a recorded deletion time; pin trash purge without --older-than removes them.\n", unknown)
+	}
+	return nil
+}
+
+// parse an age such as 30d, 2w or 12h, or any Go duration
+func parseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if amount, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(amount)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q; use a number with d, w or h, such as 30d", value)
+	}
+	return age, nil
+}
+
+// complete restore with the ids of trashed tasks
+func trashCompletions(toComplete string) []cobra.Completion {
+	entries, err := loadTrash()
+	if err != nil {
+		return nil
+	}
+	completions := []cobra.Completion{}
+	for _, entry := range entries {
+		id := strconv.Itoa(entry.id())
+		if strings.HasPrefix(id, toComplete) {
+			completions = append(completions, cobra.CompletionWithDesc(id, entry.title()))
+		}
+	}
+	return completions
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"punchlist/task"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// test listing the trash and restoring a task exactly where it was
+func TestTrashListAndRestore(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	output, _ := executeCommand("trash")
+	if !strings.Contains(output, "Trash is empty.") {
+		t.Errorf("Expected an empty trash, got %q", output)
+	}
+
+	executeCommand("todo", "Fix login bug")
+	executeCommand("todo", "Write docs")
+	path, _ := findTaskFile(2)
+	original, _ := os.ReadFile(path)
+	executeCommand("del", "2")
+
+	output, err := executeCommand("trash", "ls")
+	if err != nil {
+		t.Fatalf("trash ls failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "ID") {
+		t.Fatalf("Expected a header and one row, got:\n%s", output)
+	}
+	today := time.Now().Format("2006-01-02")
+	for _, want := range []string{"2", today, "Write docs", "002-write-docs.md"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("Expected %q in %q", want, lines[1])
+		}
+	}
+
+	output, err = executeCommand("restore", "2")
+	if err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if !strings.Contains(output, "Restored task 2: "+path) {
+		t.Errorf("Expected task 2 restored to %s, got %q", path, output)
+	}
+	if restored, _ := os.ReadFile(path); string(restored) != string(original) {
+		t.Errorf("Expected the task restored unchanged, got:\n%s", restored)
+	}
+	trashPath, _ := trashDir()
+	if _, err := os.Stat(trashMetaPath(filepath.Join(trashPath, "002-write-docs.md"))); !os.IsNotExist(err) {
+		t.Errorf("Expected the trash record to be removed, got %v", err)
+	}
+
+	output, _ = executeCommand("restore", "2")
+	if !strings.Contains(output, "no task 2 in the trash") {
+		t.Errorf("Expected nothing left to restore, got %q", output)
+	}
+}
+
+// test that restoring a task whose id has been taken gives it a new one
+func TestRestoreReassignsTakenID(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("todo", "Write docs")
+	executeCommand("del", "2")
+	// a task written by hand takes the id the deleted one had
+	tasksPath, _ := tasksDir()
+	taken := &task.Task{ID: 2, Title: "Plan launch", State: task.StateTodo, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := taken.Write(filepath.Join(tasksPath, "002-plan-launch.md")); err != nil {
+		t.Fatalf("Failed to write task: %v", err)
+	}
+
+	output, err := executeCommand("restore", "2")
+	if err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if !strings.Contains(output, "Restored task 2 as task 3") {
+		t.Errorf("Expected task 2 restored as task 3, got %q", output)
+	}
+	path, err := findTaskFile(3)
+	if err != nil {
+		t.Fatalf("Expected task 3 to exist: %v", err)
+	}
+	restored, _ := task.Parse(path)
+	if restored.Title != "Write docs" || !strings.Contains(restored.Body, "restored from trash as task 3 (task 2 was taken)") {
+		t.Errorf("Expected the restored task with a log entry, got %q:\n%s", restored.Title, restored.Body)
+	}
+	if kept, _ := task.Parse(filepath.Join(tasksPath, "002-plan-launch.md")); kept.Title != "Plan launch" {
+		t.Errorf("Expected task 2 to be left alone, got %q", kept.Title)
+	}
+
+	executeCommand("todo", "Next task")
+	if _, err := findTaskFile(4); err != nil {
+		t.Errorf("Expected the next task to get id 4: %v", err)
+	}
+}
+
+// test purging old trash and keeping entries without a deletion time
+func TestTrashPurge(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Old task")
+	executeCommand("todo", "Recent task")
+	executeCommand("todo", "Legacy task")
+	executeCommand("del", "1")
+	executeCommand("del", "2")
+	executeCommand("del", "3")
+
+	trashPath, _ := trashDir()
+	oldPath := filepath.Join(trashPath, "001-old-task.md")
+	meta := trashMeta{}
+	data, _ := os.ReadFile(trashMetaPath(oldPath))
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("Failed to read trash record: %v", err)
+	}
+	if meta.ID != 1 || meta.OriginalPath != "tasks/001-old-task.md" {
+		t.Errorf("Expected id 1 from tasks/001-old-task.md, got %+v", meta)
+	}
+	meta.DeletedAt = time.Now().AddDate(0, 0, -45)
+	data, _ = yaml.Marshal(meta)
+	os.WriteFile(trashMetaPath(oldPath), data, 0644)
+	// tasks trashed before deletion times were recorded have no record
+	os.Remove(trashMetaPath(filepath.Join(trashPath, "003-legacy-task.md")))
+
+	output, _ := executeCommand("trash")
+	if !strings.Contains(output, "unknown") {
+		t.Errorf("Expected an unknown deletion time for the legacy task, got:\n%s", output)
+	}
+
+	output, err := executeCommand("trash", "purge", "--older-than", "30d")
+	if err != nil {
+		t.Fatalf("trash purge failed: %v", err)
+	}
+	if !strings.Contains(output, "Purged 1 task from the trash.") || !strings.Contains(output, "Kept 1 without a recorded deletion time") {
+		t.Errorf("Expected one task purged and one kept, got %q", output)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the old task to be purged, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(trashPath, "002-recent-task.md")); err != nil {
+		t.Errorf("Expected the recent task to be kept: %v", err)
+	}
+
+	output, _ = executeCommand("undo")
+	if !strings.Contains(output, "Undid pin trash purge --older-than 30d") {
+		t.Errorf("Expected the purge to be undone, got %q", output)
+	}
+	if _, err := os.Stat(oldPath); err != nil {
+		t.Errorf("Expected undo to bring the old task back: %v", err)
+	}
+
+	output, _ = executeCommand("trash", "purge")
+	if !strings.Contains(output, "Purged 3 tasks from the trash.") {
+		t.Errorf("Expected every task purged, got %q", output)
+	}
+	output, _ = executeCommand("trash")
+	if !strings.Contains(output, "Trash is empty.") {
+		t.Errorf("Expected an empty trash, got %q", output)
+	}
+}
+
+// test parsing purge ages
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d":   30 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"12h":   12 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	}
+	for input, want := range tests {
+		if got, err := parseAge(input); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "d", "-3d", "soon"} {
+		if _, err := parseAge(input); err == nil {
+			t.Errorf("Expected parseAge(%q) to fail", input)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"punchlist/config"
+	"punchlist/filter"
+	"punchlist/task"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// task counts for one punchlist in a tree
+type treeCounts struct {
+	label  string
+	open   int
+	total  int
+	states map[task.State]int
+}
+
+// create the tree command
+func newTreeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tree [path] [filter]",
+		Short: "Show task counts for every punchlist in a folder tree",
+		Long: `Find every punchlist in the folder tree under path (the current directory by
+default) and show how many tasks each one has, open and per state, with a total.
+Hidden folders such as .git are skipped, as are the dependency folders
+node_modules, vendor, bower_components and venv.
+
+An optional filter expression limits which tasks are counted, as in pin ls:
+  pin tree ./services tag:launch
+
+Use pin ls --recursive to list the tasks themselves.`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			start, filterArgs := extractTargetPath(args)
+			if start == "" {
+				start = "."
+			}
+			if err := showTree(start, filterArgs); err != nil {
+				fmt.Printf("Error showing tree: %v\n", err)
+			}
+		},
+	}
+}
+
+// count tasks in every punchlist under start and print them as a table
+func showTree(start string, filterArgs []string) error {
+	projects, err := punchlistRootsFromPath(start)
+	if err != nil {
+		return err
+	}
+	expr := strings.TrimSpace(strings.Join(filterArgs, " "))
+	if err := checkFilterSyntax(expr); err != nil {
+		return err
+	}
+
+	rows := []treeCounts{}
+	states := []task.State{}
+	seen := map[task.State]bool{}
+	err = forEachProject(projects, func(p config.Project) error {
+		row, order, err := countTree(p.Alias, expr)
+		if errors.Is(err, filter.ErrUnknownState) {
+			// a state this project doesn't define matches none of its tasks
+			rows = append(rows, row)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+		for _, state := range order {
+			if !seen[state] {
+				seen[state] = true
+				states = append(states, state)
+			}
+		}
+		return nil
+	})
+	if err != nil && len(rows) > 0 {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no punchlist under %s could be read", start)
+	}
+	printTree(rows, states)
+	return nil
+}
+
+// count the active project's tasks matching expr, returning the states in
+// the project's display order
+func countTree(label, expr string) (treeCounts, []task.State, error) {
+	row := treeCounts{label: label, states: map[task.State]int{}}
+	taskFilter, err := filter.Parse(expr)
+	if err != nil {
+		return row, nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	tasksPath, err := tasksDir()
+	if err != nil {
+		return row, nil, err
+	}
+	tasks, err := selectTasks(tasksPath, taskFilter.Match)
+	if err != nil {
+		return row, nil, err
+	}
+
+	workflow := task.ActiveWorkflow()
+	for _, t := range tasks {
+		row.total++
+		row.states[t.State]++
+		if !workflow.IsClosed(t.State) {
+			row.open++
+		}
+	}
+
+	order := workflow.Names()
+	for state := range row.states {
+		if _, known := workflow.Def(state); !known {
+			order = append(order, state)
+		}
+	}
+	index := buildStateOrderIndex(loadStateOrder())
+	sort.SliceStable(order, func(i, j int) bool {
+		return stateRank(index, order[i]) < stateRank(index, order[j])
+	})
+	return row, order, nil
+}
+
+// position of a state in an order index, unknown states last
+func stateRank(index map[string]int, state task.State) int {
+	if rank, ok := index[stateOrderKey(state)]; ok {
+		return rank
+	}
+	return len(index)
+}
+
+// print one line per punchlist with open and per-state counts, leaving out
+// states no punchlist has, and a total when there is more than one
+func printTree(rows []treeCounts, states []task.State) {
+	total := treeCounts{label: "total", states: map[task.State]int{}}
+	for _, row := range rows {
+		total.open += row.open
+		total.total += row.total
+		for state, n := range row.states {
+			total.states[state] += n
+		}
+	}
+	shown := []task.State{}
+	for _, state := range states {
+		if total.states[state] > 0 {
+			shown = append(shown, state)
+		}
+	}
+
+	header := []string{"PROJECT", "OPEN", "TOTAL"}
+	for _, state := range shown {
+		header = append(header, string(state))
+	}
+	lines := [][]string{}
+	all := rows
+	if len(rows) > 1 {
+		all = append(append([]treeCounts{}, rows...), total)
+	}
+	for _, row := range all {
+		line := []string{row.label, strconv.Itoa(row.open), strconv.Itoa(row.total)}
+		for _, state := range shown {
+			line = append(line, strconv.Itoa(row.states[state]))
+		}
+		lines = append(lines, line)
+	}
+
+	widths := make([]int, len(header))
+	for i, cell := range header {
+		widths[i] = utf8.RuneCountInString(cell)
+	}
+	for _, line := range lines {
+		for i, cell := range line {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	color := colorEnabled()
+	styles := func(i int) []string {
+		if i < 3 {
+			return []string{"bold"}
+		}
+		return append([]string{"bold"}, stateStyles(shown[i-3])...)
+	}
+	printTreeLine(header, widths, styles, color)
+	for n, line := range lines {
+		isTotal := len(rows) > 1 && n == len(lines)-1
+		printTreeLine(line, widths, func(i int) []string {
+			if isTotal {
+				return []string{"bold"}
+			}
+			if i > 0 && line[i] == "0" {
+				return []string{"dim"}
+			}
+			return nil
+		}, color)
+	}
+}
+
+// print one row, the label left-aligned and counts right-aligned
+func printTreeLine(cells []string, widths []int, styles func(i int) []string, color bool) {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			b.WriteString(tableGap)
+		}
+		b.WriteString(paint(padText(cell, widths[i], i > 0), color, styles(i)...))
+	}
+	fmt.Println(b.String())
+}
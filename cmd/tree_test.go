@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"punchlist/task"
+	"strings"
+	"testing"
+)
+
+// test counts per nested punchlist and recursive listing
+func TestTreeCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	initProjectIn(t, "api", "Add auth", "Fix login")
+	initProjectIn(t, "web", "Update styles")
+	initProjectIn(t, "node_modules", "Hidden dependency")
+	withWorkingDir("api", func() error {
+		_, err := executeCommand("done", "2")
+		return err
+	})
+
+	output, _ := executeCommand("tree")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header, two projects and a total:\n%s", output)
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "PROJECT OPEN TOTAL TODO DONE" {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "api 1 2 1 1" {
+		t.Errorf("Unexpected api counts %q", lines[1])
+	}
+	if fields := strings.Fields(lines[3]); strings.Join(fields, " ") != "total 2 3 2 1" {
+		t.Errorf("Unexpected totals %q", lines[3])
+	}
+	if strings.Contains(output, "node_modules") {
+		t.Errorf("Expected node_modules to be skipped:\n%s", output)
+	}
+
+	output, _ = executeCommand("tree", "./web")
+	if strings.Contains(output, "total") || !strings.Contains(output, ".  ") {
+		t.Errorf("Expected a single project without a total:\n%s", output)
+	}
+
+	output, _ = executeCommand("ls", "--recursive", "open")
+	if !strings.Contains(output, "api ") || !strings.Contains(output, "Add auth") ||
+		!strings.Contains(output, "web ") || !strings.Contains(output, "Update styles") {
+		t.Errorf("Expected open tasks from both projects:\n%s", output)
+	}
+	if strings.Contains(output, "Fix login") || strings.Contains(output, "Hidden dependency") {
+		t.Errorf("Expected closed and skipped tasks to be left out:\n%s", output)
+	}
+
+	output, _ = executeCommand("tree", "./api/tasks")
+	if !strings.Contains(output, "no punchlist projects found") {
+		t.Errorf("Expected an error for a folder without punchlists, got %q", output)
+	}
+}
+
+// test filtering a tree by a state only some projects define
+func TestTreeFilterProjectState(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	defer task.SetWorkflow(nil)
+
+	initProjectIn(t, "a", "Write spec", "Review spec")
+	initProjectIn(t, "b", "Fix build")
+	addReviewState(t, "a")
+	withWorkingDir("a", func() error {
+		_, err := executeCommand("move", "2", "review")
+		return err
+	})
+
+	output, _ := executeCommand("tree", "state:REVIEW")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 4 || strings.Join(strings.Fields(lines[0]), " ") != "PROJECT OPEN TOTAL REVIEW" {
+		t.Fatalf("Expected REVIEW counts for both projects and a total:\n%s", output)
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "a 1 1 1" {
+		t.Errorf("Unexpected a counts %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != "b 0 0 0" {
+		t.Errorf("Unexpected b counts %q", lines[2])
+	}
+
+	output, _ = executeCommand("tree", "state:LIMBO")
+	if strings.Count(output, "invalid filter: state:LIMBO: unknown state LIMBO") != 1 {
+		t.Errorf("Expected one unknown state error when no project knows it, got %q", output)
+	}
+	output, _ = executeCommand("tree", "pri:>")
+	if strings.Count(output, "invalid filter") != 1 {
+		t.Errorf("Expected one invalid filter error, got %q", output)
+	}
+}
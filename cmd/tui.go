@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// escape sequences for the alternate screen and cursor
+const (
+	ansiEnterScreen = "\x1b[?1049h\x1b[?25l"
+	ansiLeaveScreen = "\x1b[?25h\x1b[?1049l"
+	ansiHome        = "\x1b[H"
+	ansiClearLine   = "\x1b[K"
+)
+
+// how often the screen checks for a new terminal size while idle
+const tuiResizePoll = 250 * time.Millisecond
+
+// create the tui command
+func newTuiCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tui [filter]",
+		Short: "Browse and change tasks in a full-screen interface",
+		Long: `Browse tasks in a full-screen list with a detail pane showing the selected
+task's markdown. Keys change the selected task with the same code as the
+commands, so files look the same as ones the commands write:
+
+  j/k          move              /      filter, as in pin ls
+  t s b c d x  TODO BEGUN BLOCK CONFIRM DONE NOTDO
+  m            move to any state 1-5, 0 set or clear priority
+  D            due date          n, l   add a note or log entry
+  e            open $EDITOR      ?      all keys
+  v            board view, where h/l pick a column and H/L move the card
+  q            quit
+
+An optional filter expression sets the starting filter:
+  pin tui open`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := startTUI(cmd, args); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error running tui: %v\n", err)
+			}
+		},
+	}
+	cmd.Flags().String("sort", "", "Sort keys, e.g. due,-pri,id (- for descending)")
+	cmd.Flags().Bool("board", false, "Start on the board, with a column per state")
+	return cmd
+}
+
+// load the project and run the tui until the user quits
+func startTUI(cmd *cobra.Command, args []string) error {
+	if _, err := punchlistRoot(); err != nil {
+		return err
+	}
+	sortKeys, err := sortKeysFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	m, err := newTuiModel(strings.TrimSpace(strings.Join(args, " ")), sortKeys)
+	if err != nil {
+		return err
+	}
+	if board, _ := cmd.Flags().GetBool("board"); board {
+		m.toggleBoard()
+	}
+	return runTUI(m)
+}
+
+// drive the model from the terminal in raw mode on the alternate screen
+func runTUI(m *tuiModel) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return fmt.Errorf("pin tui needs an interactive terminal")
+	}
+	saved, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	os.Stdout.WriteString(ansiEnterScreen)
+	defer func() {
+		os.Stdout.WriteString(ansiLeaveScreen)
+		term.Restore(in, saved)
+	}()
+
+	m.color = colorEnabled()
+	drawn := ""
+	m.suspend = func(fn func() error) error {
+		os.Stdout.WriteString(ansiLeaveScreen)
+		term.Restore(in, saved)
+		fnErr := fn()
+		if _, err := term.MakeRaw(in); err != nil {
+			return err
+		}
+		os.Stdout.WriteString(ansiEnterScreen)
+		drawn = ""
+		return fnErr
+	}
+
+	buf := make([]byte, 256)
+	for !m.quit {
+		if width, height, err := term.GetSize(out); err == nil {
+			m.width, m.height = width, height
+		}
+		if screen := strings.Join(m.render(), ansiClearLine+"\r\n") + ansiClearLine; screen != drawn {
+			os.Stdout.WriteString(ansiHome + screen)
+			drawn = screen
+		}
+
+		ready, err := waitForInput(in, tuiResizePoll)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			continue
+		}
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, key := range decodeKeys(buf[:n]) {
+			m.handleKey(key)
+			if m.quit {
+				break
+			}
+		}
+	}
+	return nil
+}
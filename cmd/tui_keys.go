@@ -0,0 +1,114 @@
+package cmd
+
+import "unicode/utf8"
+
+// names for keys that aren't printable; printable keys are the character
+// itself, so a key name is never a single rune
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdn"
+	keyHome      = "home"
+	keyEnd       = "end"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyDelete    = "delete"
+	keyTab       = "tab"
+	keyCtrlC     = "ctrl-c"
+	keyCtrlD     = "ctrl-d"
+	keyCtrlL     = "ctrl-l"
+	keyCtrlU     = "ctrl-u"
+	keyCtrlW     = "ctrl-w"
+)
+
+// control bytes with a key name
+var controlKeys = map[byte]string{
+	'\r': keyEnter,
+	'\n': keyEnter,
+	'\t': keyTab,
+	0x7f: keyBackspace,
+	0x08: keyBackspace,
+	0x03: keyCtrlC,
+	0x04: keyCtrlD,
+	0x0c: keyCtrlL,
+	0x15: keyCtrlU,
+	0x17: keyCtrlW,
+}
+
+// final bytes of escape sequences for cursor keys
+var csiKeys = map[byte]string{
+	'A': keyUp,
+	'B': keyDown,
+	'C': keyRight,
+	'D': keyLeft,
+	'H': keyHome,
+	'F': keyEnd,
+}
+
+// numeric parameters of escape sequences ending in ~
+var tildeKeys = map[string]string{
+	"1": keyHome,
+	"7": keyHome,
+	"4": keyEnd,
+	"8": keyEnd,
+	"3": keyDelete,
+	"5": keyPageUp,
+	"6": keyPageDown,
+}
+
+// report whether a key is a printable character rather than a named key
+func isRuneKey(key string) bool {
+	return utf8.RuneCountInString(key) == 1
+}
+
+// split raw terminal input into keys; an escape byte that doesn't start a
+// known sequence is the escape key, and unknown sequences are dropped
+func decodeKeys(buf []byte) []string {
+	keys := []string{}
+	for i := 0; i < len(buf); {
+		b := buf[i]
+		if b == 0x1b {
+			if i+1 < len(buf) && (buf[i+1] == '[' || buf[i+1] == 'O') {
+				// read parameters up to the final byte
+				j := i + 2
+				for j < len(buf) && (buf[j] < 0x40 || buf[j] > 0x7e) {
+					j++
+				}
+				if j >= len(buf) {
+					return keys
+				}
+				if buf[j] == '~' {
+					if key, ok := tildeKeys[string(buf[i+2:j])]; ok {
+						keys = append(keys, key)
+					}
+				} else if key, ok := csiKeys[buf[j]]; ok {
+					keys = append(keys, key)
+				}
+				i = j + 1
+				continue
+			}
+			keys = append(keys, keyEscape)
+			i++
+			continue
+		}
+		if key, ok := controlKeys[b]; ok {
+			keys = append(keys, key)
+			i++
+			continue
+		}
+		if b < 0x20 {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(buf[i:])
+		if r != utf8.RuneError {
+			keys = append(keys, string(r))
+		}
+		i += size
+	}
+	return keys
+}
@@ -0,0 +1,833 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"punchlist/filter"
+	"punchlist/task"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// what keys currently do in the tui
+type tuiMode int
+
+const (
+	tuiBrowse tuiMode = iota
+	tuiFilter
+	tuiPrompt
+	tuiHelp
+)
+
+// narrowest terminal that gets the detail pane beside the list
+const tuiSplitWidth = 100
+
+// keys for state changes, matching the cli commands
+var tuiStateKeys = map[string]task.State{
+	"t": task.StateTodo,
+	"s": task.StateBegun,
+	"b": task.StateBlock,
+	"c": task.StateConfirm,
+	"d": task.StateDone,
+	"x": task.StateNotDo,
+}
+
+// key help shown with ?
+var tuiHelpLines = []string{
+	"Moving",
+	"  j/k, arrows       next and previous task",
+	"  pgup/pgdn, g/G    page up and down, first and last task",
+	"  J/K               scroll the detail pane",
+	"  v                 switch between the list and the board",
+	"  h/l, arrows       on the board, the previous and next column",
+	"",
+	"Changing the selected task",
+	"  t s b c d x       TODO, BEGUN, BLOCK, CONFIRM, DONE, NOTDO",
+	"  m                 move to any state, including custom ones",
+	"  H/L, < >          on the board, move the card to the previous or next column",
+	"  1-5, 0            set priority, 0 to clear it",
+	"  D                 set the due date (today, friday, 2026-01-15, ...)",
+	"  n, l              add a note or a log entry (l is a column move on the board)",
+	"  e                 open the task file in $VISUAL or $EDITOR",
+	"",
+	"Other",
+	"  /                 filter, as in pin ls (enter keeps it, esc restores)",
+	"  esc               clear the filter",
+	"  r, ctrl-l         reload from disk",
+	"  q, ctrl-c         quit",
+	"",
+	"Press any key to go back.",
+}
+
+// a one-line question at the bottom of the screen
+type tuiPromptState struct {
+	label string
+	input string
+	// apply the answer, returning a status message
+	submit func(value string) (string, error)
+}
+
+// tuiModel holds the tui state; it never touches the terminal, so keys can
+// be fed to it and its screen checked directly
+type tuiModel struct {
+	sortKeys    []sortKey
+	all         []*task.Task
+	visible     []*task.Task
+	parseErrors int
+
+	filter      string
+	filterInput string
+	// filter to restore when editing is cancelled
+	filterBefore string
+
+	cursor       int
+	offset       int
+	detailScroll int
+
+	// board view: a column per state, with the selected card's position
+	board      bool
+	columns    []boardColumn
+	boardCol   int
+	boardRow   int
+	boardFirst int
+
+	mode      tuiMode
+	prompt    tuiPromptState
+	status    string
+	statusErr bool
+
+	width  int
+	height int
+	color  bool
+	quit   bool
+
+	// run fn with the terminal handed back, for the editor
+	suspend func(fn func() error) error
+}
+
+// load the active project into a new model
+func newTuiModel(filterExpr string, sortKeys []sortKey) (*tuiModel, error) {
+	if _, err := filter.Parse(filterExpr); err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	m := &tuiModel{
+		sortKeys: sortKeys,
+		filter:   filterExpr,
+		width:    80,
+		height:   24,
+		suspend:  func(fn func() error) error { return fn() },
+	}
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// reread every task, keeping the same task selected when it still shows
+func (m *tuiModel) reload() error {
+	tasksPath, err := tasksDir()
+	if err != nil {
+		return err
+	}
+	entries, err := loadTaskFiles(tasksPath)
+	if err != nil {
+		return err
+	}
+	m.all = m.all[:0]
+	m.parseErrors = 0
+	for _, entry := range entries {
+		if entry.parseErr != nil {
+			// stderr would garble the screen, so only count them
+			m.parseErrors++
+			continue
+		}
+		m.all = append(m.all, entry.task)
+	}
+	sortTasks(m.all, m.sortKeys, false)
+	return m.applyFilter(m.filter)
+}
+
+// show only tasks matching expr, leaving the list alone if it doesn't parse
+func (m *tuiModel) applyFilter(expr string) error {
+	taskFilter, err := filter.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	selectedID := 0
+	if t := m.selected(); t != nil {
+		selectedID = t.ID
+	}
+	m.filter = expr
+	m.visible = m.visible[:0]
+	for _, t := range m.all {
+		if taskFilter.Match(t) {
+			m.visible = append(m.visible, t)
+		}
+	}
+	m.columns = buildBoard(m.visible, false)
+	m.selectID(selectedID)
+	return nil
+}
+
+// put the list cursor and the board selection on a task, or on the first
+// task when it isn't shown
+func (m *tuiModel) selectID(id int) {
+	m.cursor = 0
+	for i, t := range m.visible {
+		if t.ID == id {
+			m.cursor = i
+			break
+		}
+	}
+	for col, column := range m.columns {
+		for row, t := range column.tasks {
+			if t.ID == id {
+				m.boardCol, m.boardRow = col, row
+				return
+			}
+		}
+	}
+	m.boardRow = 0
+	if m.boardCol >= len(m.columns) {
+		m.boardCol = 0
+	}
+}
+
+// the task under the cursor, or nil when the list or column is empty
+func (m *tuiModel) selected() *task.Task {
+	if m.board {
+		if m.boardCol >= len(m.columns) {
+			return nil
+		}
+		cards := m.columns[m.boardCol].tasks
+		if m.boardRow < 0 || m.boardRow >= len(cards) {
+			return nil
+		}
+		return cards[m.boardRow]
+	}
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return nil
+	}
+	return m.visible[m.cursor]
+}
+
+// show a status message, or an error in red
+func (m *tuiModel) setStatus(msg string, err error) {
+	if err != nil {
+		m.status, m.statusErr = err.Error(), true
+		return
+	}
+	m.status, m.statusErr = msg, false
+}
+
+// handle one key in the current mode
+func (m *tuiModel) handleKey(key string) {
+	switch m.mode {
+	case tuiHelp:
+		m.mode = tuiBrowse
+	case tuiFilter:
+		m.handleFilterKey(key)
+	case tuiPrompt:
+		m.handlePromptKey(key)
+	default:
+		m.handleBrowseKey(key)
+	}
+}
+
+// keys while browsing the list
+func (m *tuiModel) handleBrowseKey(key string) {
+	m.status = ""
+	if m.board && m.handleBoardKey(key) {
+		return
+	}
+	if state, ok := tuiStateKeys[key]; ok {
+		m.changeState(state)
+		return
+	}
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '5' {
+		priority := int(key[0] - '0')
+		m.mutate(func(id int) error { return setTaskPriority(id, priority) }, func(id int) string {
+			if priority == 0 {
+				return fmt.Sprintf("Cleared priority of task %d", id)
+			}
+			return fmt.Sprintf("Set priority of task %d to %d", id, priority)
+		})
+		return
+	}
+
+	switch key {
+	case "q", keyCtrlC:
+		m.quit = true
+	case "j", keyDown:
+		m.move(1)
+	case "k", keyUp:
+		m.move(-1)
+	case keyPageDown, " ":
+		m.move(m.listHeight())
+	case keyPageUp:
+		m.move(-m.listHeight())
+	case "g", keyHome:
+		m.move(-len(m.visible))
+	case "G", keyEnd:
+		m.move(len(m.visible))
+	case "J", keyCtrlD:
+		m.detailScroll++
+	case "K", keyCtrlU:
+		if m.detailScroll > 0 {
+			m.detailScroll--
+		}
+	case "/":
+		m.mode = tuiFilter
+		m.filterBefore = m.filter
+		m.filterInput = m.filter
+	case keyEscape:
+		if m.filter != "" {
+			m.applyFilter("")
+			m.setStatus("Filter cleared", nil)
+		}
+	case "r", keyCtrlL:
+		if err := m.reload(); err != nil {
+			m.setStatus("", err)
+		} else {
+			m.setStatus("Reloaded", nil)
+		}
+	case "?":
+		m.mode = tuiHelp
+	case "m":
+		m.ask("Move to state: ", func(value string) (string, error) {
+			state, ok := task.ParseState(value)
+			if !ok {
+				return "", fmt.Errorf("unknown state %q (known states: %s)", value, strings.Join(stateCompletionCandidates(), ", "))
+			}
+			return m.changeState(state), nil
+		})
+	case "D":
+		m.ask("Due date: ", func(value string) (string, error) {
+			due, err := parseDue(value)
+			if err != nil {
+				return "", fmt.Errorf("invalid due date: %w", err)
+			}
+			return m.mutate(func(id int) error { return setTaskDue(id, due) }, func(id int) string {
+				return fmt.Sprintf("Updated due date for task %d", id)
+			}), nil
+		})
+	case "n":
+		m.ask("Note: ", func(value string) (string, error) {
+			return m.mutate(func(id int) error { return addTaskNote(id, value) }, func(id int) string {
+				return fmt.Sprintf("Added note to task %d", id)
+			}), nil
+		})
+	case "l":
+		m.ask("Log: ", func(value string) (string, error) {
+			return m.mutate(func(id int) error { return addTaskLog(id, value) }, func(id int) string {
+				return fmt.Sprintf("Added log to task %d", id)
+			}), nil
+		})
+	case "e":
+		m.editSelected()
+	case "v":
+		m.toggleBoard()
+	}
+}
+
+// switch between the list and the board, keeping the same task selected
+func (m *tuiModel) toggleBoard() {
+	id := 0
+	if t := m.selected(); t != nil {
+		id = t.ID
+	}
+	m.board = !m.board
+	m.selectID(id)
+}
+
+// keys that mean something else on the board, reporting whether key was one
+func (m *tuiModel) handleBoardKey(key string) bool {
+	switch key {
+	case "h", keyLeft:
+		m.moveColumn(-1)
+	case "l", keyRight:
+		m.moveColumn(1)
+	case "j", keyDown:
+		m.moveCard(1)
+	case "k", keyUp:
+		m.moveCard(-1)
+	case "g", keyHome:
+		m.boardRow = 0
+	case "G", keyEnd:
+		m.moveCard(len(m.visible))
+	case "H", "<":
+		m.shiftCard(-1)
+	case "L", ">":
+		m.shiftCard(1)
+	default:
+		return false
+	}
+	return true
+}
+
+// select the next or previous column, keeping the row where possible
+func (m *tuiModel) moveColumn(delta int) {
+	col := m.boardCol + delta
+	if col < 0 || col >= len(m.columns) {
+		return
+	}
+	m.boardCol = col
+	m.moveCard(0)
+}
+
+// select another card in the column
+func (m *tuiModel) moveCard(delta int) {
+	if m.boardCol >= len(m.columns) {
+		return
+	}
+	m.boardRow += delta
+	if last := len(m.columns[m.boardCol].tasks) - 1; m.boardRow > last {
+		m.boardRow = last
+	}
+	if m.boardRow < 0 {
+		m.boardRow = 0
+	}
+}
+
+// move the selected card to the state of the next or previous column
+func (m *tuiModel) shiftCard(delta int) {
+	col := m.boardCol + delta
+	if m.selected() == nil {
+		m.setStatus("", fmt.Errorf("no task selected"))
+		return
+	}
+	if col < 0 || col >= len(m.columns) {
+		m.setStatus("", fmt.Errorf("no column there"))
+		return
+	}
+	m.changeState(m.columns[col].state)
+}
+
+// keys while typing a filter, applying it as it becomes valid
+func (m *tuiModel) handleFilterKey(key string) {
+	switch key {
+	case keyEnter:
+		if err := m.applyFilter(strings.TrimSpace(m.filterInput)); err != nil {
+			m.setStatus("", err)
+			return
+		}
+		m.mode = tuiBrowse
+		m.status = ""
+		return
+	case keyEscape, keyCtrlC:
+		m.applyFilter(m.filterBefore)
+		m.mode = tuiBrowse
+		m.status = ""
+		return
+	}
+	if !editInput(&m.filterInput, key) {
+		return
+	}
+	if err := m.applyFilter(strings.TrimSpace(m.filterInput)); err != nil {
+		m.setStatus("", err)
+	} else {
+		m.status = ""
+	}
+}
+
+// keys while answering a prompt
+func (m *tuiModel) handlePromptKey(key string) {
+	switch key {
+	case keyEnter:
+		value := strings.TrimSpace(m.prompt.input)
+		m.mode = tuiBrowse
+		if value == "" {
+			m.status = ""
+			return
+		}
+		msg, err := m.prompt.submit(value)
+		if err != nil {
+			m.setStatus("", err)
+		} else if msg != "" {
+			m.setStatus(msg, nil)
+		}
+	case keyEscape, keyCtrlC:
+		m.mode = tuiBrowse
+		m.status = ""
+	default:
+		editInput(&m.prompt.input, key)
+	}
+}
+
+// apply a line-editing key to input, reporting whether it changed
+func editInput(input *string, key string) bool {
+	switch {
+	case key == keyBackspace:
+		if *input == "" {
+			return false
+		}
+		_, size := utf8.DecodeLastRuneInString(*input)
+		*input = (*input)[:len(*input)-size]
+	case key == keyCtrlU:
+		*input = ""
+	case key == keyCtrlW:
+		trimmed := strings.TrimRight(*input, " ")
+		*input = trimmed[:strings.LastIndex(trimmed, " ")+1]
+	case isRuneKey(key):
+		*input += key
+	default:
+		return false
+	}
+	return true
+}
+
+// start a prompt about the selected task
+func (m *tuiModel) ask(label string, submit func(value string) (string, error)) {
+	if m.selected() == nil {
+		m.setStatus("", fmt.Errorf("no task selected"))
+		return
+	}
+	m.mode = tuiPrompt
+	m.prompt = tuiPromptState{label: label, submit: submit}
+}
+
+// move the selected task to a state, returning the status shown
+func (m *tuiModel) changeState(state task.State) string {
+	return m.mutate(func(id int) error { return updateTaskStateSingle(id, state, "") }, func(id int) string {
+		return fmt.Sprintf("Task %d moved to %s", id, state)
+	})
+}
+
+// change the selected task with the same code as the cli, under the
+// project lock, then reload; returns the status shown
+func (m *tuiModel) mutate(change func(id int) error, done func(id int) string) string {
+	t := m.selected()
+	if t == nil {
+		m.setStatus("", fmt.Errorf("no task selected"))
+		return ""
+	}
+	if err := withProjectLock(func() error { return change(t.ID) }); err != nil {
+		m.setStatus("", fmt.Errorf("task %d: %w", t.ID, err))
+		return ""
+	}
+	msg := done(t.ID)
+	if err := m.reload(); err != nil {
+		m.setStatus("", err)
+		return ""
+	}
+	m.setStatus(msg, nil)
+	return msg
+}
+
+// open the selected task in the editor and reload afterwards
+func (m *tuiModel) editSelected() {
+	t := m.selected()
+	if t == nil {
+		m.setStatus("", fmt.Errorf("no task selected"))
+		return
+	}
+	err := m.suspend(func() error { return editTask(t.ID) })
+	if reloadErr := m.reload(); err == nil {
+		err = reloadErr
+	}
+	if err == nil {
+		// an edit that changes the title renames the file
+		if path, findErr := findTaskFile(t.ID); findErr != nil {
+			err = findErr
+		} else if _, parseErr := task.Parse(path); parseErr != nil {
+			err = fmt.Errorf("%s no longer parses: %w", filepath.Base(path), parseErr)
+		}
+	}
+	m.setStatus(fmt.Sprintf("Edited task %d", t.ID), err)
+}
+
+// move the cursor, keeping it on the list
+func (m *tuiModel) move(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	m.detailScroll = 0
+}
+
+// report whether the detail pane sits beside the list
+func (m *tuiModel) split() bool {
+	return m.width >= tuiSplitWidth
+}
+
+// rows available to the list
+func (m *tuiModel) listHeight() int {
+	body := m.height - 2
+	if !m.split() {
+		body = (body - 1) / 2
+	}
+	if body < 1 {
+		body = 1
+	}
+	return body
+}
+
+// draw the whole screen as exactly height lines
+func (m *tuiModel) render() []string {
+	width, height := m.width, m.height
+	if width < 20 {
+		width = 20
+	}
+	if height < 5 {
+		height = 5
+	}
+	lines := []string{m.renderHeader(width)}
+	bodyHeight := height - 2
+
+	if m.mode == tuiHelp {
+		for i := 0; i < bodyHeight; i++ {
+			text := ""
+			if i < len(tuiHelpLines) {
+				text = tuiHelpLines[i]
+			}
+			lines = append(lines, padText(truncateText(width, text), width, false))
+		}
+		return append(lines, m.renderFooter(width))
+	}
+
+	if m.board {
+		shown := boardColumnsShown(len(m.columns), width)
+		if m.boardCol < m.boardFirst {
+			m.boardFirst = m.boardCol
+		}
+		if m.boardCol >= m.boardFirst+shown {
+			m.boardFirst = m.boardCol - shown + 1
+		}
+		board := renderBoard(m.columns, boardLayout{
+			width:          width,
+			height:         bodyHeight - 2,
+			color:          m.color,
+			first:          m.boardFirst,
+			selectedColumn: m.boardCol,
+			selectedRow:    m.boardRow,
+		})
+		lines = append(lines, board...)
+		return append(lines, m.renderFooter(width))
+	}
+
+	listHeight := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+listHeight {
+		m.offset = m.cursor - listHeight + 1
+	}
+
+	if m.split() {
+		listWidth := width * 11 / 20
+		detailWidth := width - listWidth - 3
+		list := m.renderList(listWidth, bodyHeight)
+		detail := m.renderDetail(detailWidth, bodyHeight)
+		for i := 0; i < bodyHeight; i++ {
+			lines = append(lines, list[i]+paint(" │ ", m.color, "dim")+detail[i])
+		}
+	} else {
+		detailHeight := bodyHeight - listHeight - 1
+		lines = append(lines, m.renderList(width, listHeight)...)
+		lines = append(lines, paint(strings.Repeat("─", width), m.color, "dim"))
+		lines = append(lines, m.renderDetail(width, detailHeight)...)
+	}
+	return append(lines, m.renderFooter(width))
+}
+
+// the top line: project, counts and filter
+func (m *tuiModel) renderHeader(width int) string {
+	name := "pin"
+	if root, err := punchlistRoot(); err == nil {
+		name = "pin " + filepath.Base(root)
+	}
+	text := fmt.Sprintf(" %s  %d of %d tasks", name, len(m.visible), len(m.all))
+	if m.filter != "" {
+		text += "  filter: " + m.filter
+	}
+	if m.parseErrors > 0 {
+		text += fmt.Sprintf("  (%d unreadable, see pin doctor)", m.parseErrors)
+	}
+	text += "  ? for help"
+	return paint(padText(truncateText(width, text), width, false), m.color, "reverse")
+}
+
+// list rows, the selected one highlighted
+func (m *tuiModel) renderList(width, height int) []string {
+	idWidth, stateWidth := 1, 0
+	for _, t := range m.visible {
+		if n := len(strconv.Itoa(t.ID)); n > idWidth {
+			idWidth = n
+		}
+		if n := utf8.RuneCountInString(string(t.State)); n > stateWidth {
+			stateWidth = n
+		}
+	}
+	now := time.Now()
+
+	lines := make([]string, 0, height)
+	for row := 0; row < height; row++ {
+		i := m.offset + row
+		if i >= len(m.visible) {
+			text := ""
+			if i == 0 {
+				text = " No matching tasks."
+			}
+			lines = append(lines, padText(text, width, false))
+			continue
+		}
+		t := m.visible[i]
+		pri, due := "  ", ""
+		if t.Priority > 0 {
+			pri = "p" + strconv.Itoa(t.Priority)
+		}
+		if t.Due != nil {
+			due = dueRelative(*t.Due, now)
+		}
+		prefix := fmt.Sprintf(" %*d %s %s ", idWidth, t.ID, padText(string(t.State), stateWidth, false), pri)
+		titleWidth := width - utf8.RuneCountInString(prefix)
+		if due != "" {
+			titleWidth -= utf8.RuneCountInString(due) + 1
+		}
+		title := padText(truncateText(titleWidth, t.Title), titleWidth, false)
+		text := prefix + title
+		if due != "" {
+			text += " " + due
+		}
+		text = padText(truncateText(width, text), width, false)
+
+		if i == m.cursor {
+			lines = append(lines, paint(text, m.color, "reverse"))
+			continue
+		}
+		if !m.color || titleWidth < 1 {
+			lines = append(lines, text)
+			continue
+		}
+		// color the state, priority and due date in place
+		styled := fmt.Sprintf(" %*d %s %s %s",
+			idWidth, t.ID,
+			paint(padText(string(t.State), stateWidth, false), true, stateStyles(t.State)...),
+			paint(pri, true, cellStyles(t, "priority", now)...),
+			title)
+		if due != "" {
+			styled += " " + paint(due, true, cellStyles(t, "due", now)...)
+		}
+		lines = append(lines, styled+strings.Repeat(" ", width-utf8.RuneCountInString(text)))
+	}
+	return lines
+}
+
+// the selected task's fields and markdown body, scrolled by detailScroll
+func (m *tuiModel) renderDetail(width, height int) []string {
+	content := []string{}
+	if t := m.selected(); t != nil {
+		now := time.Now()
+		content = append(content, paint(truncateText(width, fmt.Sprintf("#%d %s", t.ID, t.Title)), m.color, "bold"))
+		fields := []string{"State: " + string(t.State)}
+		if t.Priority > 0 {
+			fields = append(fields, fmt.Sprintf("Priority: %d", t.Priority))
+		}
+		if t.Due != nil {
+			fields = append(fields, fmt.Sprintf("Due: %s (%s)", formatDueDate(t.Due), dueRelative(*t.Due, now)))
+		}
+		summary := strings.Join(fields, "  ")
+		if utf8.RuneCountInString(summary) > width {
+			summary = truncateText(width, summary)
+		} else {
+			state := string(t.State)
+			summary = "State: " + paint(state, m.color, stateStyles(t.State)...) + strings.TrimPrefix(summary, "State: "+state)
+		}
+		content = append(content, summary)
+		if len(t.Tags) > 0 {
+			content = append(content, truncateText(width, "Tags: "+strings.Join(t.Tags, ", ")))
+		}
+		content = append(content, paint(truncateText(width, filepath.Base(t.Path())), m.color, "dim"), "")
+		for _, line := range strings.Split(strings.TrimRight(t.Body, "\n"), "\n") {
+			content = append(content, wrapIndented(line, width)...)
+		}
+	}
+
+	scroll := m.detailScroll
+	if last := len(content) - height; scroll > last {
+		scroll = last
+	}
+	if scroll < 0 {
+		scroll = 0
+	}
+	m.detailScroll = scroll
+
+	lines := make([]string, 0, height)
+	for i := 0; i < height; i++ {
+		text := ""
+		if scroll+i < len(content) {
+			text = content[scroll+i]
+		}
+		lines = append(lines, text+strings.Repeat(" ", max(0, width-visibleWidth(text))))
+	}
+	return lines
+}
+
+// the bottom line: a prompt, the filter being typed, a status or key hints
+func (m *tuiModel) renderFooter(width int) string {
+	var text string
+	styles := []string{}
+	switch {
+	case m.mode == tuiFilter:
+		text = "/" + m.filterInput + "█"
+		if m.status != "" {
+			text += "   " + m.status
+			styles = append(styles, "red")
+		}
+	case m.mode == tuiPrompt:
+		text = m.prompt.label + m.prompt.input + "█"
+	case m.status != "":
+		text = m.status
+		if m.statusErr {
+			styles = append(styles, "red")
+		}
+	case m.board:
+		text = "h/l column  j/k card  H/L move card  t s b c d x state  / filter  v list  ? help  q quit"
+		styles = append(styles, "dim")
+	default:
+		text = "j/k move  / filter  t s b c d x state  m move  1-5 pri  D due  n note  l log  e edit  v board  q quit"
+		styles = append(styles, "dim")
+	}
+	return paint(padText(truncateText(width, text), width, false), m.color, styles...)
+}
+
+// wrap a body line to width, keeping its leading indentation
+func wrapIndented(line string, width int) []string {
+	trimmed := strings.TrimLeft(line, " \t")
+	if trimmed == "" {
+		return []string{""}
+	}
+	indent := line[:len(line)-len(trimmed)]
+	if len(indent) >= width/2 {
+		indent = ""
+	}
+	wrapped := wrapText(trimmed, width-len(indent))
+	for i := range wrapped {
+		wrapped[i] = indent + wrapped[i]
+	}
+	return wrapped
+}
+
+// width of text in runes, ignoring ansi escape sequences
+func visibleWidth(text string) int {
+	n := 0
+	for i := 0; i < len(text); {
+		if text[i] == 0x1b {
+			end := strings.IndexByte(text[i:], 'm')
+			if end < 0 {
+				break
+			}
+			i += end + 1
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		n++
+	}
+	return n
+}
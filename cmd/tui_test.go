@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"punchlist/task"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// test decoding raw terminal input into keys
+func TestDecodeKeys(t *testing.T) {
+	input := []byte("j\x1b[A\x1b[B\x1b[6~\x1bOH\r\x7fé\x1b\x03\x1b[99z")
+	expected := []string{"j", keyUp, keyDown, keyPageDown, keyHome, keyEnter, keyBackspace, "é", keyEscape, keyCtrlC}
+	if got := decodeKeys(input); !reflect.DeepEqual(got, expected) {
+		t.Errorf("decodeKeys = %q, want %q", got, expected)
+	}
+}
+
+// feed keys to the model, typing any text that isn't a named key
+func pressKeys(m *tuiModel, keys ...string) {
+	named := map[string]bool{keyEnter: true, keyEscape: true, keyCtrlU: true, keyBackspace: true, keyDown: true, keyUp: true}
+	for _, key := range keys {
+		if named[key] {
+			m.handleKey(key)
+			continue
+		}
+		for _, r := range key {
+			m.handleKey(string(r))
+		}
+	}
+}
+
+// test that tui keys change task files the same way the commands do
+func TestTuiMutations(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Write release notes")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("todo", "Plan offsite")
+
+	keys, _ := parseSortKeys("id")
+	m, err := newTuiModel("", keys)
+	if err != nil {
+		t.Fatalf("newTuiModel failed: %v", err)
+	}
+	if len(m.visible) != 3 || m.selected().ID != 1 {
+		t.Fatalf("Expected three tasks with the first selected, got %d", len(m.visible))
+	}
+
+	pressKeys(m, "j", "s", "2", "n", "check the logs", keyEnter, "D", "2026-03-01", keyEnter)
+	path, _ := findTaskFile(2)
+	updated, err := task.Parse(path)
+	if err != nil {
+		t.Fatalf("Failed to parse task 2: %v", err)
+	}
+	if updated.State != task.StateBegun || updated.StartedAt == nil {
+		t.Errorf("Expected task 2 to be started, got %s", updated.State)
+	}
+	if updated.Priority != 2 {
+		t.Errorf("Expected priority 2, got %d", updated.Priority)
+	}
+	if updated.Due == nil || updated.Due.Format("2006-01-02") != "2026-03-01" {
+		t.Errorf("Expected a due date of 2026-03-01, got %v", updated.Due)
+	}
+	for _, expected := range []string{"## Notes", "check the logs", "state changed from TODO to BEGUN", "priority set to 2", "added due date"} {
+		if !strings.Contains(updated.Body, expected) {
+			t.Errorf("Expected %q in the body:\n%s", expected, updated.Body)
+		}
+	}
+	if m.selected().ID != 2 || m.status != "Updated due date for task 2" {
+		t.Errorf("Expected task 2 to stay selected with a status, got %d %q", m.selected().ID, m.status)
+	}
+
+	// a rejected move shows an error and changes nothing
+	pressKeys(m, "m", "nowhere", keyEnter)
+	if !m.statusErr || !strings.Contains(m.status, "unknown state") {
+		t.Errorf("Expected an unknown state error, got %q", m.status)
+	}
+	pressKeys(m, "n", "ignored", keyEscape)
+	if after, _ := task.Parse(path); strings.Contains(after.Body, "ignored") {
+		t.Errorf("Expected an escaped prompt to change nothing")
+	}
+}
+
+// test filtering, cancelling and clearing the filter
+func TestTuiFilter(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Write release notes", "tags:{launch}")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("done", "2")
+
+	m, err := newTuiModel("open", []sortKey{{field: "id"}})
+	if err != nil {
+		t.Fatalf("newTuiModel failed: %v", err)
+	}
+	if len(m.visible) != 1 {
+		t.Fatalf("Expected the starting filter to hide closed tasks, got %d", len(m.visible))
+	}
+
+	pressKeys(m, "/", keyCtrlU, "tag:(")
+	if !m.statusErr || m.filter == "tag:(" {
+		t.Errorf("Expected an invalid filter to keep the last valid one, got %q %q", m.filter, m.status)
+	}
+	pressKeys(m, keyEscape)
+	if m.filter != "open" || m.mode != tuiBrowse {
+		t.Errorf("Expected escape to restore the filter, got %q", m.filter)
+	}
+
+	pressKeys(m, "/", keyCtrlU, "done", keyEnter)
+	if m.filter != "done" || len(m.visible) != 1 || m.visible[0].ID != 2 {
+		t.Errorf("Expected the done filter to show task 2, got %q %d", m.filter, len(m.visible))
+	}
+	pressKeys(m, keyEscape)
+	if m.filter != "" || len(m.visible) != 2 {
+		t.Errorf("Expected escape to clear the filter, got %q %d", m.filter, len(m.visible))
+	}
+}
+
+// test that the screen fills the terminal in both layouts
+func TestTuiRender(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Write release notes", "pri:1")
+	executeCommand("note", "1", "remember the changelog")
+
+	m, err := newTuiModel("", []sortKey{{field: "id"}})
+	if err != nil {
+		t.Fatalf("newTuiModel failed: %v", err)
+	}
+	for _, size := range [][2]int{{120, 30}, {60, 24}} {
+		m.width, m.height = size[0], size[1]
+		lines := m.render()
+		if len(lines) != size[1] {
+			t.Errorf("Expected %d lines at %dx%d, got %d", size[1], size[0], size[1], len(lines))
+		}
+		for i, line := range lines {
+			if n := utf8.RuneCountInString(line); n != size[0] {
+				t.Errorf("Expected line %d to be %d wide at %dx%d, got %d: %q", i, size[0], size[0], size[1], n, line)
+			}
+		}
+		screen := strings.Join(lines, "\n")
+		for _, expected := range []string{"Write release notes", "#1 Write release notes", "Priority: 1", "remember the changelog"} {
+			if !strings.Contains(screen, expected) {
+				t.Errorf("Expected %q on the %dx%d screen:\n%s", expected, size[0], size[1], screen)
+			}
+		}
+	}
+
+	m.width, m.height = 100, 30
+	pressKeys(m, "?")
+	if !strings.Contains(strings.Join(m.render(), "\n"), "Press any key to go back.") {
+		t.Errorf("Expected ? to show the help")
+	}
+	pressKeys(m, "x")
+	if m.mode != tuiBrowse {
+		t.Errorf("Expected any key to leave the help")
+	}
+	if path, _ := findTaskFile(1); path != "" {
+		if after, _ := task.Parse(path); after.State != task.StateTodo {
+			t.Errorf("Expected the key that left the help not to change the task, got %s", after.State)
+		}
+	}
+	pressKeys(m, "q")
+	if !m.quit {
+		t.Errorf("Expected q to quit")
+	}
+}
+
+// test moving between board columns and moving cards across them
+func TestTuiBoard(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Write release notes")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("start", "2")
+
+	m, err := newTuiModel("", []sortKey{{field: "id"}})
+	if err != nil {
+		t.Fatalf("newTuiModel failed: %v", err)
+	}
+	m.width, m.height = 120, 20
+	pressKeys(m, "v")
+	if !m.board || m.selected() == nil || m.selected().ID != 1 {
+		t.Fatalf("Expected the board with task 1 still selected")
+	}
+	if m.columns[m.boardCol].state != task.StateTodo {
+		t.Errorf("Expected the selection in the TODO column, got %s", m.columns[m.boardCol].state)
+	}
+
+	// BEGUN comes before TODO in the default order
+	pressKeys(m, "H")
+	path, _ := findTaskFile(1)
+	if moved, _ := task.Parse(path); moved.State != task.StateBlock {
+		t.Errorf("Expected H to move the card to the previous column, got %s", moved.State)
+	}
+	if m.selected().ID != 1 || m.columns[m.boardCol].state != task.StateBlock {
+		t.Errorf("Expected the selection to follow the card")
+	}
+
+	pressKeys(m, "h")
+	if m.selected() == nil || m.selected().ID != 2 {
+		t.Errorf("Expected h to select the BEGUN column's card")
+	}
+	screen := strings.Join(m.render(), "\n")
+	if !strings.Contains(screen, "BEGUN (1)") || !strings.Contains(screen, "BLOCK (1)") || !strings.Contains(screen, "H/L move card") {
+		t.Errorf("Expected the board on screen:\n%s", screen)
+	}
+
+	pressKeys(m, "v")
+	if m.board || m.selected().ID != 2 {
+		t.Errorf("Expected v to return to the list on the same task")
+	}
+}
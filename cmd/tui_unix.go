@@ -0,0 +1,27 @@
+//go:build unix
+
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// wait up to timeout for input on fd so the screen can follow resizes
+func waitForInput(fd int, timeout time.Duration) (bool, error) {
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout/time.Millisecond))
+	if errors.Is(err, unix.EINTR) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	// some systems can't poll terminals; fall back to a blocking read
+	if fds[0].Revents&unix.POLLNVAL != 0 {
+		return true, nil
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// create the undo command
+func newUndoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [n]",
+		Short: "Undo the last change, or the last n changes",
+		Long: `Put task files back the way they were before the last command that changed
+them. Every command that changes tasks is recorded in .punchlist/journal, up to
+journal_limit operations (default 100):
+  pin undo
+  pin undo 3
+
+Undo stops, changing nothing, if a file it would restore has been edited since
+the command ran, so hand edits are never lost. pin history lists what can be
+undone.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := countArg(args)
+			if err != nil {
+				fmt.Printf("Invalid count: %v\n", err)
+				return
+			}
+			if err := undoOperations(n); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error undoing: %v\n", err)
+			}
+		},
+	}
+}
+
+// create the redo command
+func newRedoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "redo [n]",
+		Short: "Redo the last undone change, or the last n",
+		Long: `Apply changes that pin undo reverted, oldest first. Any new change after an
+undo discards what could be redone.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := countArg(args)
+			if err != nil {
+				fmt.Printf("Invalid count: %v\n", err)
+				return
+			}
+			if err := redoOperations(n); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error redoing: %v\n", err)
+			}
+		},
+	}
+}
+
+// create the history command
+func newHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List recent changes to the project",
+		Long: `List recent commands that changed the project, newest first, with the files
+each one touched. These are what pin undo and pin redo work through:
+  pin history
+  pin history -n 5
+
+pin show prints a single task's history.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			limit, _ := cmd.Flags().GetInt("limit")
+			if err := showOperations(limit); err != nil {
+				if printNotPunchlistError(err) {
+					return
+				}
+				fmt.Printf("Error showing history: %v\n", err)
+			}
+		},
+	}
+	cmd.Flags().IntP("limit", "n", 20, "Show at most this many operations (0 for all)")
+	return cmd
+}
+
+// read an optional count argument, 1 when absent
+func countArg(args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("must be at least 1, got %d", n)
+	}
+	return n, nil
+}
+
+// revert the last n applied operations, newest first, stopping at one whose
+// files have changed since
+func undoOperations(n int) error {
+	root, err := punchlistRoot()
+	if err != nil {
+		return err
+	}
+	return withProjectLockUnrecorded(func() error {
+		entries, err := loadJournal(root)
+		if err != nil {
+			return err
+		}
+		applied := []*journalEntry{}
+		for _, entry := range entries {
+			if !entry.Undone {
+				applied = append(applied, entry)
+			}
+		}
+		if len(applied) == 0 {
+			fmt.Println("Nothing to undo.")
+			return nil
+		}
+		for i := 0; i < n; i++ {
+			if len(applied) == 0 {
+				fmt.Println("Nothing more to undo.")
+				return nil
+			}
+			entry := applied[len(applied)-1]
+			if err := checkJournalFiles(root, entry.Files, journalAfter); err != nil {
+				return fmt.Errorf("can't undo %s: %w, so undo stopped to keep that change", entry.Command, err)
+			}
+			if err := applyJournalFiles(root, entry.Files, journalBefore); err != nil {
+				return err
+			}
+			entry.Undone = true
+			if err := saveJournalEntry(root, entry); err != nil {
+				return err
+			}
+			fmt.Printf("Undid %s (%s)\n", entry.Command, journalFileSummary(entry.Files))
+			applied = applied[:len(applied)-1]
+		}
+		return nil
+	})
+}
+
+// reapply the first n undone operations, oldest first
+func redoOperations(n int) error {
+	root, err := punchlistRoot()
+	if err != nil {
+		return err
+	}
+	return withProjectLockUnrecorded(func() error {
+		entries, err := loadJournal(root)
+		if err != nil {
+			return err
+		}
+		undone := []*journalEntry{}
+		for _, entry := range entries {
+			if entry.Undone {
+				undone = append(undone, entry)
+			}
+		}
+		if len(undone) == 0 {
+			fmt.Println("Nothing to redo.")
+			return nil
+		}
+		for i := 0; i < n; i++ {
+			if i >= len(undone) {
+				fmt.Println("Nothing more to redo.")
+				return nil
+			}
+			entry := undone[i]
+			if err := checkJournalFiles(root, entry.Files, journalBefore); err != nil {
+				return fmt.Errorf("can't redo %s: %w, so redo stopped to keep that change", entry.Command, err)
+			}
+			if err := applyJournalFiles(root, entry.Files, journalAfter); err != nil {
+				return err
+			}
+			entry.Undone = false
+			if err := saveJournalEntry(root, entry); err != nil {
+				return err
+			}
+			fmt.Printf("Redid %s (%s)\n", entry.Command, journalFileSummary(entry.Files))
+		}
+		return nil
+	})
+}
+
+// list the most recent operations, newest first, marking undone ones
+func showOperations(limit int) error {
+	root, err := punchlistRoot()
+	if err != nil {
+		return err
+	}
+	entries, err := loadJournal(root)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No recorded changes.")
+		return nil
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	seqWidth, commandWidth := 1, 0
+	for _, entry := range entries {
+		seqWidth = max(seqWidth, len(strconv.Itoa(entry.Seq)))
+		commandWidth = max(commandWidth, len(entry.Command))
+	}
+	color := colorEnabled()
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		line := fmt.Sprintf("%*d  %s  %-*s  %s", seqWidth, entry.Seq, entry.At.Local().Format("2006-01-02 15:04"),
+			commandWidth, entry.Command, journalFileSummary(entry.Files))
+		if entry.Undone {
+			fmt.Println(paint(line+"  (undone)", color, "dim"))
+			continue
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"punchlist/task"
+	"strings"
+	"testing"
+)
+
+// test undoing and redoing a sequence of commands
+func TestUndoRedo(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("done", "1")
+	executeCommand("note", "1", "shipped in 1.2")
+	path, _ := findTaskFile(1)
+
+	output, err := executeCommand("undo")
+	if err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if !strings.Contains(output, "Undid pin note 1 \"shipped in 1.2\" (tasks/001-fix-login-bug.md)") {
+		t.Errorf("Expected the note to be undone, got %q", output)
+	}
+	if undone, _ := task.Parse(path); undone.State != task.StateDone || strings.Contains(undone.Body, "shipped in 1.2") {
+		t.Errorf("Expected a done task without the note, got %s:\n%s", undone.State, undone.Body)
+	}
+
+	output, _ = executeCommand("undo", "2")
+	if !strings.Contains(output, "Undid pin done 1") || !strings.Contains(output, "Undid pin todo \"Fix login bug\"") {
+		t.Errorf("Expected the state change and the create to be undone, got %q", output)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected undoing the create to remove the task, got %v", err)
+	}
+	output, _ = executeCommand("undo")
+	if !strings.Contains(output, "Nothing to undo.") {
+		t.Errorf("Expected nothing left to undo, got %q", output)
+	}
+
+	output, _ = executeCommand("history")
+	if strings.Count(output, "(undone)") != 3 {
+		t.Errorf("Expected three undone operations, got:\n%s", output)
+	}
+
+	output, _ = executeCommand("redo", "2")
+	if !strings.Contains(output, "Redid pin todo") || !strings.Contains(output, "Redid pin done 1") {
+		t.Errorf("Expected the create and state change to be redone, got %q", output)
+	}
+	if redone, err := task.Parse(path); err != nil || redone.State != task.StateDone {
+		t.Errorf("Expected task 1 back as DONE, got %v %v", redone, err)
+	}
+
+	// a new change drops what could still be redone
+	executeCommand("log", "1", "verified")
+	output, _ = executeCommand("redo")
+	if !strings.Contains(output, "Nothing to redo.") {
+		t.Errorf("Expected nothing to redo after a new change, got %q", output)
+	}
+}
+
+// test that undo refuses to overwrite a hand edit
+func TestUndoRefusesHandEdits(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("start", "1")
+	path, _ := findTaskFile(1)
+	edited, _ := os.ReadFile(path)
+	edited = append(edited, []byte("\nextra notes typed by hand\n")...)
+	if err := os.WriteFile(path, edited, 0644); err != nil {
+		t.Fatalf("Failed to edit task: %v", err)
+	}
+
+	output, _ := executeCommand("undo")
+	if !strings.Contains(output, "can't undo pin start 1: tasks/001-fix-login-bug.md has been changed since") {
+		t.Errorf("Expected undo to refuse, got %q", output)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(edited) {
+		t.Errorf("Expected the hand edit to be kept, got:\n%s", after)
+	}
+}
+
+// test undoing a delete and a compact
+func TestUndoDeleteAndCompact(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "First")
+	executeCommand("todo", "Second")
+	executeCommand("todo", "Third")
+
+	executeCommand("del", "2")
+	executeCommand("compact")
+	if _, err := os.Stat(filepath.Join("tasks", "002-third.md")); err != nil {
+		t.Fatalf("Expected compact to renumber the third task: %v", err)
+	}
+
+	output, _ := executeCommand("undo")
+	if !strings.Contains(output, "Undid pin compact") {
+		t.Errorf("Expected compact to be undone, got %q", output)
+	}
+	if _, err := os.Stat(filepath.Join("tasks", "003-third.md")); err != nil {
+		t.Errorf("Expected the third task back at id 3: %v", err)
+	}
+
+	executeCommand("undo")
+	if _, err := os.Stat(filepath.Join("tasks", "002-second.md")); err != nil {
+		t.Errorf("Expected the deleted task back: %v", err)
+	}
+	if entries, _ := os.ReadDir(".trash"); len(entries) != 0 {
+		t.Errorf("Expected the trash to be empty again, got %d entries", len(entries))
+	}
+}
+
+// test that history lists operations newest first, marking undone ones
+func TestHistoryCmd(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Fix login bug")
+	executeCommand("set", "1", "pri:2")
+	executeCommand("undo")
+
+	output, err := executeCommand("history")
+	if err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "pin set 1 pri:2") || !strings.HasSuffix(lines[0], "(undone)") ||
+		!strings.Contains(lines[1], "pin todo \"Fix login bug\"  tasks/001-fix-login-bug.md") {
+		t.Errorf("Unexpected history:\n%s", output)
+	}
+	output, _ = executeCommand("history", "-n", "1")
+	if strings.Contains(output, "pin todo") {
+		t.Errorf("Expected only the latest operation, got:\n%s", output)
+	}
+}
+
+// test that an operation records only the files it touched, without reading
+// earlier journal entries
+func TestJournalRecordsTouchedFiles(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "First")
+	executeCommand("todo", "Second")
+	root, _ := punchlistRoot()
+	// a damaged old entry would stop recording if entries were decoded
+	os.WriteFile(journalEntryPath(root, 1, false), []byte("not json"), 0644)
+
+	executeCommand("note", "2", "only this one")
+	names, _ := journalNames(root)
+	if len(names) != 3 || names[2].seq != 3 {
+		t.Fatalf("Expected a third journal entry, got %+v", names)
+	}
+	data, _ := os.ReadFile(journalEntryPath(root, 3, false))
+	entry := &journalEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		t.Fatalf("Failed to read journal entry: %v", err)
+	}
+	if len(entry.Files) != 1 || entry.Files[0].Path != "tasks/002-second.md" {
+		t.Errorf("Expected only the noted task to be recorded, got %+v", entry.Files)
+	}
+}
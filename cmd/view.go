@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"punchlist/config"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// prefix that names a saved view in ls arguments
+const viewPrefix = "@"
+
+// create the view command
+func newViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view [name] [filter]",
+		Short: "List tasks with a saved view, or list the views",
+		Long: `List tasks with a view saved under views: in .punchlist/config.yaml.
+pin view today is the same as pin ls @today. Without a name, list the views.
+
+Extra filter terms narrow the view, and flags override its settings:
+  pin view today tag:launch
+  pin view today --format json`,
+		ValidArgsFunction: viewArgCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				if err := listViews(); err != nil {
+					if printNotPunchlistError(err) {
+						return
+					}
+					fmt.Printf("Error listing views: %v\n", err)
+				}
+				return
+			}
+			args[0] = viewPrefix + strings.TrimPrefix(args[0], viewPrefix)
+			runList(cmd, args)
+		},
+	}
+	addListFlags(cmd)
+	return cmd
+}
+
+// print each view with its description or filter
+func listViews() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	names := sortedViewNames(cfg)
+	if len(names) == 0 {
+		fmt.Printf("No views defined. Add them under views: in %s.\n", configPathHint())
+		return nil
+	}
+	width := 0
+	for _, name := range names {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	for _, name := range names {
+		view := cfg.Views[name]
+		summary := view.Description
+		if summary == "" {
+			summary = view.Filter
+		}
+		fmt.Println(strings.TrimRight(fmt.Sprintf("%s%-*s  %s", viewPrefix, width, name, summary), " "))
+	}
+	return nil
+}
+
+// apply a leading @view argument to the flags, returning the filter to use
+func applyViewArgs(cmd *cobra.Command, filterArgs []string) ([]string, error) {
+	if len(filterArgs) == 0 || !strings.HasPrefix(filterArgs[0], viewPrefix) {
+		return filterArgs, nil
+	}
+	name := strings.TrimPrefix(filterArgs[0], viewPrefix)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	view, ok := cfg.Views[name]
+	if !ok {
+		names := sortedViewNames(cfg)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown view %q: no views are defined in %s", name, configPathHint())
+		}
+		return nil, fmt.Errorf("unknown view %q (have %s)", name, strings.Join(names, ", "))
+	}
+	if err := applyView(cmd, view); err != nil {
+		return nil, fmt.Errorf("view %s: %w", name, err)
+	}
+
+	extra := strings.TrimSpace(strings.Join(filterArgs[1:], " "))
+	switch {
+	case view.Filter == "":
+		return filterArgs[1:], nil
+	case extra == "":
+		return []string{view.Filter}, nil
+	default:
+		return []string{"(" + view.Filter + ") and (" + extra + ")"}, nil
+	}
+}
+
+// set flags from a view, leaving any the user passed alone
+func applyView(cmd *cobra.Command, view config.ViewConfig) error {
+	flags := cmd.Flags()
+	set := func(name, value string) error {
+		if value == "" || flags.Changed(name) {
+			return nil
+		}
+		return flags.Set(name, value)
+	}
+	if err := set("sort", view.Sort); err != nil {
+		return err
+	}
+	if err := set("columns", strings.Join(view.Columns, ",")); err != nil {
+		return err
+	}
+	// an output choice on the command line replaces the view's
+	if flags.Changed("format") || flags.Changed("template") || flags.Changed("template-name") {
+		return nil
+	}
+	if err := set("format", view.Format); err != nil {
+		return err
+	}
+	return set("template", view.Template)
+}
+
+// view names in order
+func sortedViewNames(cfg *config.Config) []string {
+	names := make([]string, 0, len(cfg.Views))
+	for name := range cfg.Views {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// config path relative to the project root, for messages
+func configPathHint() string {
+	return config.PunchlistDir + "/config.yaml"
+}
+
+// view names with the @ prefix that match toComplete
+func viewCompletions(toComplete string) []cobra.Completion {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil
+	}
+	completions := []cobra.Completion{}
+	for _, name := range sortedViewNames(cfg) {
+		candidate := viewPrefix + name
+		if !strings.HasPrefix(candidate, toComplete) {
+			continue
+		}
+		description := cfg.Views[name].Description
+		if description == "" {
+			description = cfg.Views[name].Filter
+		}
+		completions = append(completions, cobra.CompletionWithDesc(candidate, description))
+	}
+	return completions
+}
+
+// complete the view name for pin view
+func viewArgCompletion(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := viewCompletions(viewPrefix + strings.TrimPrefix(toComplete, viewPrefix))
+	for i, completion := range completions {
+		completions[i] = cobra.Completion(strings.TrimPrefix(string(completion), viewPrefix))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
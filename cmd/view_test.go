@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"punchlist/config"
+	"strings"
+	"testing"
+)
+
+// test saved views through ls @name and pin view
+func TestSavedViews(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+
+	executeCommand("init")
+	executeCommand("todo", "Later launch task", "pri:2", "by:2026-03-01", "tags:{launch}")
+	executeCommand("todo", "Sooner launch task", "pri:1", "by:2026-02-01", "tags:{launch}")
+	executeCommand("todo", "Unrelated")
+	executeCommand("done", "2")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.Views = map[string]config.ViewConfig{
+		"launch": {
+			Description: "Launch work",
+			Filter:      "tag:launch",
+			Sort:        "due",
+			Columns:     []string{"id", "state", "title", "due"},
+		},
+		"export": {Format: "csv", Columns: []string{"id", "title"}},
+	}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	output, _ := executeCommand("ls", "@launch")
+	expected := "ID  STATE  TITLE               DUE\n" +
+		" 2  DONE   Sooner launch task  2026-02-01\n" +
+		" 1  TODO   Later launch task   2026-03-01\n"
+	if output != expected {
+		t.Errorf("Unexpected view output:\n%q\nwant\n%q", output, expected)
+	}
+
+	output, _ = executeCommand("view", "launch", "open")
+	if strings.Contains(output, "Sooner") || !strings.Contains(output, "Later launch task") {
+		t.Errorf("Expected extra filter to narrow the view, got:\n%s", output)
+	}
+
+	output, _ = executeCommand("ls", "@launch", "--sort", "-due", "--columns", "id")
+	if output != "ID\n 1\n 2\n" {
+		t.Errorf("Expected flags to override the view, got %q", output)
+	}
+
+	output, _ = executeCommand("ls", "@export")
+	if !strings.HasPrefix(output, "id,title\n1,Later launch task\n") {
+		t.Errorf("Expected csv from view format, got %q", output)
+	}
+
+	output, _ = executeCommand("view")
+	if output != "@export\n@launch  Launch work\n" {
+		t.Errorf("Unexpected view list: %q", output)
+	}
+
+	output, _ = executeCommand("ls", "@missing")
+	if !strings.Contains(output, `unknown view "missing" (have export, launch)`) {
+		t.Errorf("Expected unknown view error, got %q", output)
+	}
+
+	completions := viewCompletions("@l")
+	if len(completions) != 1 || !strings.HasPrefix(string(completions[0]), "@launch") {
+		t.Errorf("Unexpected view completions: %v", completions)
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"punchlist/config"
+	"punchlist/task"
+	"strings"
+)
+
+// transition key that applies to every state
+const anyStateKey = "*"
+
+// load the active project's states and transitions into the task package
+func applyProjectWorkflow() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		task.SetWorkflow(nil)
+		return
+	}
+	workflow, err := workflowFromConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring states in %s/config.yaml: %v\n", config.PunchlistDir, err)
+		task.SetWorkflow(nil)
+		return
+	}
+	task.SetWorkflow(workflow)
+}
+
+// build a workflow from the built-in states plus config declarations
+func workflowFromConfig(cfg *config.Config) (*task.Workflow, error) {
+	workflow := task.DefaultWorkflow()
+	for _, declared := range cfg.States {
+		name := task.State(strings.ToUpper(strings.TrimSpace(declared.Name)))
+		if name == "" {
+			return nil, fmt.Errorf("state without a name")
+		}
+		if strings.ContainsAny(string(name), " \t,:") {
+			return nil, fmt.Errorf("state name %q may not contain spaces, commas, or colons", declared.Name)
+		}
+		if existing, ok := workflow.Lookup(string(name)); ok && existing != name {
+			return nil, fmt.Errorf("state %s is already an alias of %s", name, existing)
+		}
+		for _, alias := range declared.Aliases {
+			if existing, ok := workflow.Lookup(alias); ok && existing != name {
+				return nil, fmt.Errorf("alias %s of %s is already used by %s", alias, name, existing)
+			}
+		}
+
+		// extend a built-in state or add a new one
+		merged := false
+		for i := range workflow.States {
+			if workflow.States[i].Name == name {
+				workflow.States[i].Aliases = append(workflow.States[i].Aliases, declared.Aliases...)
+				workflow.States[i].Closed = workflow.States[i].Closed || declared.Closed
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			workflow.States = append(workflow.States, task.StateDef{
+				Name:    name,
+				Aliases: declared.Aliases,
+				Closed:  declared.Closed,
+			})
+		}
+	}
+
+	if len(cfg.Transitions) == 0 {
+		return workflow, nil
+	}
+
+	// resolve transition targets, expanding the * wildcard
+	resolveTargets := func(labels []string) ([]task.State, error) {
+		targets := []task.State{}
+		for _, label := range labels {
+			if strings.TrimSpace(label) == anyStateKey {
+				return workflow.Names(), nil
+			}
+			state, ok := workflow.Lookup(label)
+			if !ok {
+				return nil, fmt.Errorf("unknown state %q in transitions", label)
+			}
+			targets = append(targets, state)
+		}
+		return targets, nil
+	}
+
+	workflow.Transitions = map[task.State][]task.State{}
+	if labels, ok := cfg.Transitions[anyStateKey]; ok {
+		targets, err := resolveTargets(labels)
+		if err != nil {
+			return nil, err
+		}
+		for _, state := range workflow.Names() {
+			workflow.Transitions[state] = targets
+		}
+	}
+	for from, labels := range cfg.Transitions {
+		if strings.TrimSpace(from) == anyStateKey {
+			continue
+		}
+		state, ok := workflow.Lookup(from)
+		if !ok {
+			return nil, fmt.Errorf("unknown state %q in transitions", from)
+		}
+		targets, err := resolveTargets(labels)
+		if err != nil {
+			return nil, err
+		}
+		workflow.Transitions[state] = targets
+	}
+	return workflow, nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"punchlist/config"
+	"punchlist/task"
+	"strings"
+	"testing"
+)
+
+// test custom states and transition rules from config
+func TestCustomStatesAndTransitions(t *testing.T) {
+	teardown := setupTest(t)
+	defer teardown()
+	defer task.SetWorkflow(nil)
+
+	executeCommand("init")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	cfg.States = []config.StateConfig{
+		{Name: "REVIEW", Aliases: []string{"rev"}},
+		{Name: "QA"},
+	}
+	cfg.Transitions = map[string][]string{
+		"TODO":   {"BEGUN"},
+		"BEGUN":  {"REVIEW"},
+		"REVIEW": {"QA", "BEGUN"},
+		"QA":     {"DONE"},
+	}
+	cfg.LsStateOrder = []string{"REVIEW", "BEGUN", "TODO"}
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	executeCommand("todo", "Ship feature")
+	executeCommand("review", "Already in review")
+
+	content, err := os.ReadFile(filepath.Join("tasks", "002-already-in-review.md"))
+	if err != nil {
+		t.Fatalf("Failed to read task file: %v", err)
+	}
+	if !strings.Contains(string(content), "state: REVIEW") {
+		t.Errorf("Expected a task created in REVIEW. File content:\n%s", content)
+	}
+
+	output, _ := executeCommand("move", "1", "rev")
+	if !strings.Contains(output, "cannot move from TODO to REVIEW") || !strings.Contains(output, "may only move to BEGUN") {
+		t.Errorf("Expected the transition to be rejected with an explanation. Got: %s", output)
+	}
+
+	executeCommand("start", "1")
+	output, _ = executeCommand("move", "1", "rev")
+	if !strings.Contains(output, "Task 1 moved to REVIEW") {
+		t.Errorf("Expected task 1 to move to REVIEW. Got: %s", output)
+	}
+
+	output, err = executeCommand("ls", "review")
+	if err != nil {
+		t.Fatalf("ls review failed: %v", err)
+	}
+	if !strings.Contains(output, "Ship feature") || !strings.Contains(output, "Already in review") {
+		t.Errorf("ls review should list both review tasks. Got: %s", output)
+	}
+
+	if got := stateCompletions("Q"); len(got) != 1 || got[0] != "QA" {
+		t.Errorf("Expected QA completion, got %v", got)
+	}
+}
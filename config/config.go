@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"punchlist/fsutil"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,14 +14,54 @@ import (
 // punchlist config directory name
 const PunchlistDir = ".punchlist"
 
+// RootEnvVar names the environment variable that pins the punchlist root.
+const RootEnvVar = "PUNCHLIST_ROOT"
+
 // ErrPunchlistNotFound indicates no punchlist directory exists in scope.
 var ErrPunchlistNotFound = errors.New("punchlist directory not found")
 
+// RootSource describes how the punchlist root was chosen.
+type RootSource string
+
+const (
+	RootFromFlag   RootSource = "--root flag"
+	RootFromEnv    RootSource = RootEnvVar
+	RootFromCwd    RootSource = "current directory"
+	RootFromParent RootSource = "parent directory"
+)
+
+// explicit root set by the --root flag, empty when unset
+var rootOverride string
+
 // config holds persisted settings for a punchlist scope
 type Config struct {
-	NextID       int      `yaml:"next_id"`
-	IDWidth      int      `yaml:"id_width,omitempty"`
-	LsStateOrder []string `yaml:"ls_state_order,omitempty"`
+	NextID       int                   `yaml:"next_id"`
+	IDWidth      int                   `yaml:"id_width,omitempty"`
+	LsStateOrder []string              `yaml:"ls_state_order,omitempty"`
+	LsSort       string                `yaml:"ls_sort,omitempty"`
+	LockTimeout  string                `yaml:"lock_timeout,omitempty"`
+	JournalLimit int                   `yaml:"journal_limit,omitempty"`
+	SearchIndex  bool                  `yaml:"search_index,omitempty"`
+	Views        map[string]ViewConfig `yaml:"views,omitempty"`
+	States       []StateConfig         `yaml:"states,omitempty"`
+	Transitions  map[string][]string   `yaml:"transitions,omitempty"`
+}
+
+// StateConfig declares a custom state or extends a built-in one.
+type StateConfig struct {
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases,omitempty"`
+	Closed  bool     `yaml:"closed,omitempty"`
+}
+
+// ViewConfig is a saved set of ls options, run with pin ls @name.
+type ViewConfig struct {
+	Description string   `yaml:"description,omitempty"`
+	Filter      string   `yaml:"filter,omitempty"`
+	Sort        string   `yaml:"sort,omitempty"`
+	Columns     []string `yaml:"columns,omitempty"`
+	Format      string   `yaml:"format,omitempty"`
+	Template    string   `yaml:"template,omitempty"`
 }
 
 // default id width for filename padding
@@ -27,51 +69,143 @@ func DefaultIDWidth() int {
 	return 3
 }
 
+// default wait for another pin process to release the project lock
+func DefaultLockTimeout() time.Duration {
+	return 10 * time.Second
+}
+
+// default number of operations kept for pin undo
+func DefaultJournalLimit() int {
+	return 100
+}
+
 // default state order for ls
 func DefaultLsStateOrder() []string {
 	return []string{"BEGUN", "BLOCK", "TODO", "CONFIRM", "DONE", "NOTDO"}
 }
 
-// find the punchlist directory in the current working directory
+// SetRootOverride pins root resolution to an explicit directory.
+// An empty path restores normal discovery.
+func SetRootOverride(path string) {
+	rootOverride = path
+}
+
+// RootOverride returns the explicit root set with SetRootOverride.
+func RootOverride() string {
+	return rootOverride
+}
+
+// find the punchlist directory in startDir or its ancestors, stopping at
+// the top of a git work tree or a filesystem boundary
 func findPunchlistDir(startDir string) (string, error) {
-	punchlistPath := filepath.Join(startDir, PunchlistDir)
-	info, err := os.Stat(punchlistPath)
-	if err == nil && info.IsDir() {
-		return punchlistPath, nil
-	}
-	if err != nil && !os.IsNotExist(err) {
-		return "", fmt.Errorf("could not access %s directory: %w", PunchlistDir, err)
+	dir := filepath.Clean(startDir)
+	for {
+		punchlistPath := filepath.Join(dir, PunchlistDir)
+		info, err := os.Stat(punchlistPath)
+		if err == nil && info.IsDir() {
+			return punchlistPath, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("could not access %s directory: %w", PunchlistDir, err)
+		}
+
+		// a .git entry marks the top of a repository; don't escape it
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", ErrPunchlistNotFound
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || crossesFilesystem(dir, parent) {
+			return "", ErrPunchlistNotFound
+		}
+		dir = parent
 	}
-	return "", ErrPunchlistNotFound
 }
 
-func FindPunchlistRoot() (string, error) {
-	cwd, err := os.Getwd()
+// report whether moving from dir to parent changes filesystems
+func crossesFilesystem(dir, parent string) bool {
+	dirInfo, err := os.Stat(dir)
 	if err != nil {
-		return "", fmt.Errorf("could not get current working directory: %w", err)
+		return true
 	}
-
-	punchlistPath, err := findPunchlistDir(cwd)
+	parentInfo, err := os.Stat(parent)
 	if err != nil {
-		return "", err
+		return true
 	}
+	return !sameDevice(dirInfo, parentInfo)
+}
 
-	return filepath.Dir(punchlistPath), nil
+// ValidateRoot checks that path is a punchlist root and returns it absolute.
+func ValidateRoot(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve path: %w", err)
+	}
+	info, err := os.Stat(filepath.Join(absPath, PunchlistDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrPunchlistNotFound
+		}
+		return "", fmt.Errorf("could not access %s: %w", PunchlistDir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s exists but is not a directory at %s", PunchlistDir, absPath)
+	}
+	return absPath, nil
 }
 
-// load config from the nearest punchlist directory
-func LoadConfig() (*Config, error) {
+// ResolveRoot picks the punchlist root and reports how it was found.
+// The --root flag wins over PUNCHLIST_ROOT, which wins over searching
+// upward from the current working directory.
+func ResolveRoot() (string, RootSource, error) {
+	if rootOverride != "" {
+		root, err := ValidateRoot(rootOverride)
+		if err != nil {
+			return "", RootFromFlag, fmt.Errorf("--root %s: %w", rootOverride, err)
+		}
+		return root, RootFromFlag, nil
+	}
+	if envRoot := os.Getenv(RootEnvVar); envRoot != "" {
+		root, err := ValidateRoot(envRoot)
+		if err != nil {
+			return "", RootFromEnv, fmt.Errorf("%s=%s: %w", RootEnvVar, envRoot, err)
+		}
+		return root, RootFromEnv, nil
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
-		return nil, fmt.Errorf("could not get current working directory: %w", err)
+		return "", RootFromCwd, fmt.Errorf("could not get current working directory: %w", err)
 	}
-
 	punchlistPath, err := findPunchlistDir(cwd)
+	if err != nil {
+		return "", RootFromCwd, err
+	}
+	root := filepath.Dir(punchlistPath)
+	if root == filepath.Clean(cwd) {
+		return root, RootFromCwd, nil
+	}
+	return root, RootFromParent, nil
+}
+
+// FindPunchlistRoot returns the directory holding the active .punchlist.
+func FindPunchlistRoot() (string, error) {
+	root, _, err := ResolveRoot()
+	return root, err
+}
+
+// load config from the active punchlist root
+func LoadConfig() (*Config, error) {
+	root, err := FindPunchlistRoot()
 	if err != nil {
 		return nil, err
 	}
+	return LoadConfigFrom(root)
+}
 
-	configPath := filepath.Join(punchlistPath, "config.yaml")
+// LoadConfigFrom loads config from the punchlist at root.
+func LoadConfigFrom(root string) (*Config, error) {
+	configPath := filepath.Join(root, PunchlistDir, "config.yaml")
 	f, err := os.Open(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not open config file: %w. please run 'pin init'", err)
@@ -86,23 +220,23 @@ func LoadConfig() (*Config, error) {
 	return &cfg, nil
 }
 
-// save config to the nearest punchlist directory
+// save config to the active punchlist root
 func SaveConfig(cfg *Config) error {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
 	// we assume .punchlist exists when saving.
-	punchlistPath, err := findPunchlistDir(cwd)
+	root, err := FindPunchlistRoot()
 	if err != nil {
 		return err
 	}
+	return SaveConfigTo(root, cfg)
+}
 
-	configPath := filepath.Join(punchlistPath, "config.yaml")
+// SaveConfigTo saves config to the punchlist at root.
+func SaveConfigTo(root string, cfg *Config) error {
+	configPath := filepath.Join(root, PunchlistDir, "config.yaml")
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("could not marshal config: %w", err)
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	return fsutil.WriteFile(configPath, data, 0644)
 }
@@ -67,6 +67,90 @@ func TestFindPunchlistDir(t *testing.T) {
 			t.Errorf("Expected an error, but got none")
 		}
 	})
+
+	// test case 4: a git work tree stops the upward search
+	t.Run("stops at git repository root", func(t *testing.T) {
+		outerDir := filepath.Join(sandboxDir, "test4")
+		repoDir := filepath.Join(outerDir, "repo")
+		childDir := filepath.Join(repoDir, "child")
+		if err := os.MkdirAll(filepath.Join(outerDir, PunchlistDir), 0755); err != nil {
+			t.Fatalf("Failed to create punchlist dir: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Join(repoDir, ".git"), 0755); err != nil {
+			t.Fatalf("Failed to create git dir: %v", err)
+		}
+		if err := os.MkdirAll(childDir, 0755); err != nil {
+			t.Fatalf("Failed to create child dir: %v", err)
+		}
+
+		if foundDir, err := findPunchlistDir(childDir); err == nil {
+			t.Errorf("Expected search to stop at the repo root, but found %s", foundDir)
+		}
+	})
+}
+
+// test root selection precedence
+func TestResolveRoot(t *testing.T) {
+	sandboxDir, err := filepath.Abs("sandbox")
+	if err != nil {
+		t.Fatalf("Failed to get absolute path for sandbox: %v", err)
+	}
+	defer os.RemoveAll(sandboxDir)
+
+	cwdRoot := filepath.Join(sandboxDir, "resolve_cwd")
+	envRoot := filepath.Join(sandboxDir, "resolve_env")
+	flagRoot := filepath.Join(sandboxDir, "resolve_flag")
+	for _, dir := range []string{cwdRoot, envRoot, flagRoot} {
+		if err := os.MkdirAll(filepath.Join(dir, PunchlistDir), 0755); err != nil {
+			t.Fatalf("Failed to create punchlist dir: %v", err)
+		}
+	}
+	subDir := filepath.Join(cwdRoot, "tasks")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatalf("Failed to create sub dir: %v", err)
+	}
+
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	if err := os.Chdir(subDir); err != nil {
+		t.Fatalf("Failed to change directory to %s: %v", subDir, err)
+	}
+	defer os.Chdir(originalWd)
+
+	t.Setenv(RootEnvVar, "")
+	root, source, err := ResolveRoot()
+	if err != nil {
+		t.Fatalf("ResolveRoot failed: %v", err)
+	}
+	if root != cwdRoot || source != RootFromParent {
+		t.Errorf("Expected %s from %s, got %s from %s", cwdRoot, RootFromParent, root, source)
+	}
+
+	t.Setenv(RootEnvVar, envRoot)
+	root, source, err = ResolveRoot()
+	if err != nil {
+		t.Fatalf("ResolveRoot failed: %v", err)
+	}
+	if root != envRoot || source != RootFromEnv {
+		t.Errorf("Expected %s from %s, got %s from %s", envRoot, RootFromEnv, root, source)
+	}
+
+	SetRootOverride(flagRoot)
+	defer SetRootOverride("")
+	root, source, err = ResolveRoot()
+	if err != nil {
+		t.Fatalf("ResolveRoot failed: %v", err)
+	}
+	if root != flagRoot || source != RootFromFlag {
+		t.Errorf("Expected %s from %s, got %s from %s", flagRoot, RootFromFlag, root, source)
+	}
+
+	SetRootOverride(filepath.Join(sandboxDir, "missing"))
+	if _, _, err := ResolveRoot(); err == nil {
+		t.Errorf("Expected an error for a root without %s", PunchlistDir)
+	}
 }
 
 // test load and save config round-trip
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package config
+
+import "os"
+
+// device ids are not exposed here, so never treat a parent as a boundary
+func sameDevice(a, b os.FileInfo) bool {
+	return true
+}
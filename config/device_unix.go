@@ -0,0 +1,21 @@
+//go:build unix
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// compare the devices backing two stat results
+func sameDevice(a, b os.FileInfo) bool {
+	as, ok := a.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+	bs, ok := b.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+	return as.Dev == bs.Dev
+}
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"punchlist/fsutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RegistryFile is the name of the project registry inside RegistryDir.
+const RegistryFile = "projects.yaml"
+
+// Project is a registered punchlist root and its short alias.
+type Project struct {
+	Alias string `yaml:"alias"`
+	Path  string `yaml:"path"`
+}
+
+// Registry lists the punchlist projects known on this machine.
+type Registry struct {
+	Projects []Project `yaml:"projects"`
+}
+
+// RegistryDir returns the per-user folder holding the registry,
+// $XDG_CONFIG_HOME/punchlist or ~/.config/punchlist.
+func RegistryDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "punchlist"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "punchlist"), nil
+}
+
+// LoadRegistry reads the registry; a missing file is an empty registry.
+func LoadRegistry() (*Registry, error) {
+	dir, err := RegistryDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, RegistryFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Registry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	reg := &Registry{}
+	if err := yaml.Unmarshal(data, reg); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filepath.Join(dir, RegistryFile), err)
+	}
+	return reg, nil
+}
+
+// SaveRegistry writes the registry, creating its folder if needed.
+func SaveRegistry(reg *Registry) error {
+	dir, err := RegistryDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFile(filepath.Join(dir, RegistryFile), data, 0644)
+}
+
+// UpdateRegistry loads the registry, applies fn and saves the result while
+// holding a lock, so concurrent pin runs don't drop each other's changes.
+func UpdateRegistry(fn func(reg *Registry) error) error {
+	dir, err := RegistryDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	lock, err := fsutil.LockFile(filepath.Join(dir, "lock"), DefaultLockTimeout())
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	reg, err := LoadRegistry()
+	if err != nil {
+		return err
+	}
+	if err := fn(reg); err != nil {
+		return err
+	}
+	return SaveRegistry(reg)
+}
+
+// Find returns the project with the given alias.
+func (r *Registry) Find(alias string) (Project, bool) {
+	for _, p := range r.Projects {
+		if p.Alias == alias {
+			return p, true
+		}
+	}
+	return Project{}, false
+}
+
+// FindPath returns the project registered for a root path.
+func (r *Registry) FindPath(path string) (Project, bool) {
+	path = filepath.Clean(path)
+	for _, p := range r.Projects {
+		if filepath.Clean(p.Path) == path {
+			return p, true
+		}
+	}
+	return Project{}, false
+}
+
+// Add registers an absolute root under an alias derived from its folder
+// name, returning the existing entry if the root is already registered.
+func (r *Registry) Add(path string) (Project, bool) {
+	if p, ok := r.FindPath(path); ok {
+		return p, false
+	}
+	base := sanitizeAlias(filepath.Base(path))
+	if base == "" {
+		base = "project"
+	}
+	alias := base
+	for n := 2; ; n++ {
+		if _, taken := r.Find(alias); !taken {
+			break
+		}
+		alias = base + "-" + strconv.Itoa(n)
+	}
+	p := Project{Alias: alias, Path: filepath.Clean(path)}
+	r.Projects = append(r.Projects, p)
+	return p, true
+}
+
+// SetAlias renames the project known by an alias or path.
+func (r *Registry) SetAlias(target, alias string) (Project, error) {
+	if err := ValidateAlias(alias); err != nil {
+		return Project{}, err
+	}
+	index := r.index(target)
+	if index < 0 {
+		return Project{}, fmt.Errorf("no registered project %q", target)
+	}
+	if other, taken := r.Find(alias); taken && other.Path != r.Projects[index].Path {
+		return Project{}, fmt.Errorf("alias %q is already used by %s", alias, other.Path)
+	}
+	r.Projects[index].Alias = alias
+	return r.Projects[index], nil
+}
+
+// Remove drops the project known by an alias or path.
+func (r *Registry) Remove(target string) (Project, error) {
+	index := r.index(target)
+	if index < 0 {
+		return Project{}, fmt.Errorf("no registered project %q", target)
+	}
+	p := r.Projects[index]
+	r.Projects = append(r.Projects[:index], r.Projects[index+1:]...)
+	return p, nil
+}
+
+// position of a project by alias, then by path
+func (r *Registry) index(target string) int {
+	target = strings.TrimPrefix(target, "@")
+	for i, p := range r.Projects {
+		if p.Alias == target {
+			return i
+		}
+	}
+	if abs, err := filepath.Abs(target); err == nil {
+		for i, p := range r.Projects {
+			if filepath.Clean(p.Path) == abs {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// ValidateAlias rejects aliases that can't be written as @alias in a list.
+func ValidateAlias(alias string) error {
+	if alias == "" {
+		return fmt.Errorf("alias can't be empty")
+	}
+	if sanitizeAlias(alias) != alias {
+		return fmt.Errorf("invalid alias %q: use lower-case letters, digits, '-', '_' or '.'", alias)
+	}
+	return nil
+}
+
+// keep the characters allowed in aliases, lower-cased
+func sanitizeAlias(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-.")
+}
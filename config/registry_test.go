@@ -0,0 +1,60 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// test alias generation, renaming and removal
+func TestRegistryAliases(t *testing.T) {
+	reg := &Registry{}
+	work, added := reg.Add("/home/me/Work Stuff")
+	if !added || work.Alias != "work-stuff" {
+		t.Errorf("Expected alias work-stuff, got %+v", work)
+	}
+	other, _ := reg.Add("/tmp/work-stuff")
+	if other.Alias != "work-stuff-2" {
+		t.Errorf("Expected a numbered alias for a taken name, got %q", other.Alias)
+	}
+	if _, added := reg.Add("/home/me/Work Stuff/"); added {
+		t.Errorf("Expected an already registered path not to be added twice")
+	}
+
+	if _, err := reg.SetAlias("@work-stuff-2", "work-stuff"); err == nil {
+		t.Errorf("Expected an alias in use to be rejected")
+	}
+	if _, err := reg.SetAlias("work-stuff-2", "Tmp"); err == nil {
+		t.Errorf("Expected an upper-case alias to be rejected")
+	}
+	if p, err := reg.SetAlias("/tmp/work-stuff", "tmp"); err != nil || p.Alias != "tmp" {
+		t.Errorf("Expected rename by path, got %+v %v", p, err)
+	}
+	if _, err := reg.Remove("tmp"); err != nil || len(reg.Projects) != 1 {
+		t.Errorf("Expected remove by alias, got %v %+v", err, reg.Projects)
+	}
+}
+
+// test that the registry is saved under XDG_CONFIG_HOME
+func TestRegistryRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	err := UpdateRegistry(func(reg *Registry) error {
+		reg.Add("/srv/project")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateRegistry failed: %v", err)
+	}
+	regDir, _ := RegistryDir()
+	if regDir != filepath.Join(dir, "punchlist") {
+		t.Errorf("Unexpected registry dir %s", regDir)
+	}
+	reg, err := LoadRegistry()
+	if err != nil {
+		t.Fatalf("LoadRegistry failed: %v", err)
+	}
+	if p, ok := reg.Find("project"); !ok || p.Path != "/srv/project" {
+		t.Errorf("Expected saved project, got %+v", reg.Projects)
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// folders FindRoots never descends into: installed dependencies, which can
+// be large and never hold punchlists of their own. Hidden folders such as
+// .git are skipped as well. Build output folders aren't listed, since a
+// service can be named build or dist.
+var skippedDirNames = []string{"node_modules", "vendor", "bower_components", "venv"}
+
+// report whether FindRoots skips a folder with this name
+func skipDir(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	for _, skipped := range skippedDirNames {
+		if name == skipped {
+			return true
+		}
+	}
+	return false
+}
+
+// FindRoots walks the tree under dir and returns every punchlist root in it,
+// dir included, as absolute paths in walk order. Roots may be nested; their
+// tasks folders aren't searched. Symlinks are not followed.
+func FindRoots(dir string) ([]string, error) {
+	start, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve path: %w", err)
+	}
+	info, err := os.Stat(start)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	roots := []string{}
+	err = filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// an unreadable folder shouldn't hide the rest of the tree
+			if path != start && d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != start && skipDir(name) {
+			return fs.SkipDir
+		}
+		if parent := filepath.Dir(path); path != start && name == "tasks" && isRoot(parent) {
+			return fs.SkipDir
+		}
+		if isRoot(path) {
+			roots = append(roots, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+// report whether dir holds a .punchlist folder
+func isRoot(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, PunchlistDir))
+	return err == nil && info.IsDir()
+}
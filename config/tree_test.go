@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// test that nested roots are found and skipped folders are left alone
+func TestFindRoots(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{
+		".punchlist",
+		"services/api/.punchlist",
+		"services/web/worker/.punchlist",
+		// build output folder names are fair game for services
+		"services/build/.punchlist",
+		"node_modules/pkg/.punchlist",
+		".git/modules/x/.punchlist",
+		"services/api/tasks/nested/.punchlist",
+		"docs",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", sub, err)
+		}
+	}
+
+	roots, err := FindRoots(dir)
+	if err != nil {
+		t.Fatalf("FindRoots failed: %v", err)
+	}
+	expected := []string{
+		dir,
+		filepath.Join(dir, "services", "api"),
+		filepath.Join(dir, "services", "build"),
+		filepath.Join(dir, "services", "web", "worker"),
+	}
+	if len(roots) != len(expected) {
+		t.Fatalf("Expected roots %v, got %v", expected, roots)
+	}
+	for i := range expected {
+		if roots[i] != expected[i] {
+			t.Errorf("Expected root %d to be %s, got %s", i, expected[i], roots[i])
+		}
+	}
+
+	roots, err = FindRoots(filepath.Join(dir, "docs"))
+	if err != nil || len(roots) != 0 {
+		t.Errorf("Expected no roots under docs, got %v %v", roots, err)
+	}
+	if _, err := FindRoots(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("Expected an error for a missing folder")
+	}
+}
@@ -0,0 +1,118 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a moment or a whole day parsed from a filter value
+type timeValue struct {
+	start time.Time
+	// end is the exclusive end of a day; unused for exact moments
+	end time.Time
+	day bool
+}
+
+// parse dates like 2026-01-15, today, +7d, or -2w relative to now
+func parseTimeValue(input string, now time.Time) (timeValue, error) {
+	value := strings.ToLower(strings.TrimSpace(input))
+	loc := now.Location()
+
+	switch value {
+	case "now":
+		return timeValue{start: now}, nil
+	case "today":
+		return dayValue(now, 0), nil
+	case "tomorrow":
+		return dayValue(now, 1), nil
+	case "yesterday":
+		return dayValue(now, -1), nil
+	}
+
+	if offset, ok := parseOffset(value, now); ok {
+		return offset, nil
+	}
+
+	if parsed, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return dayValue(parsed, 0), nil
+	}
+	for _, layout := range []string{"2006-01-02t15:04", "2006-01-02t15:04:05"} {
+		if parsed, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return timeValue{start: parsed}, nil
+		}
+	}
+	if parsed, err := time.Parse(time.RFC3339, strings.ToUpper(value)); err == nil {
+		return timeValue{start: parsed}, nil
+	}
+
+	return timeValue{}, fmt.Errorf("invalid date: %s", input)
+}
+
+// parse +3h, -2d, +1w, +1m, or +1y; hours are exact, the rest are days
+func parseOffset(value string, now time.Time) (timeValue, bool) {
+	if len(value) < 3 || (value[0] != '+' && value[0] != '-') {
+		return timeValue{}, false
+	}
+	unitStart := len(value) - 1
+	for unitStart > 1 && (value[unitStart-1] < '0' || value[unitStart-1] > '9') {
+		unitStart--
+	}
+	amount, err := strconv.Atoi(value[1:unitStart])
+	if err != nil {
+		return timeValue{}, false
+	}
+	if value[0] == '-' {
+		amount = -amount
+	}
+
+	switch value[unitStart:] {
+	case "h":
+		return timeValue{start: now.Add(time.Duration(amount) * time.Hour)}, true
+	case "d":
+		return dayValue(now, amount), true
+	case "w":
+		return dayValue(now, amount*7), true
+	case "m", "mo":
+		return dayValue(now.AddDate(0, amount, 0), 0), true
+	case "y":
+		return dayValue(now.AddDate(amount, 0, 0), 0), true
+	}
+	return timeValue{}, false
+}
+
+// the whole local day addDays after t
+func dayValue(t time.Time, addDays int) timeValue {
+	day := t.AddDate(0, 0, addDays)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, t.Location())
+	return timeValue{start: start, end: start.AddDate(0, 0, 1), day: true}
+}
+
+// compare a timestamp against a moment or day
+func compareTime(t time.Time, op string, value timeValue) bool {
+	switch op {
+	case "<":
+		return t.Before(value.start)
+	case "<=":
+		if value.day {
+			return t.Before(value.end)
+		}
+		return !t.After(value.start)
+	case ">":
+		if value.day {
+			return !t.Before(value.end)
+		}
+		return t.After(value.start)
+	case ">=":
+		return !t.Before(value.start)
+	case "=", ":":
+		if value.day {
+			return !t.Before(value.start) && t.Before(value.end)
+		}
+		return t.Equal(value.start)
+	case "!=":
+		return !compareTime(t, "=", value)
+	}
+	return false
+}
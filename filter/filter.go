@@ -0,0 +1,481 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"punchlist/task"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownState marks an expression naming a state the active workflow
+// doesn't define, which another project's workflow may accept.
+var ErrUnknownState = errors.New("unknown state")
+
+// stateError explains an unknown state while matching ErrUnknownState.
+type stateError struct {
+	msg string
+}
+
+// Error returns the explanation.
+func (e *stateError) Error() string {
+	return e.msg
+}
+
+// Is reports whether target is ErrUnknownState.
+func (e *stateError) Is(target error) bool {
+	return target == ErrUnknownState
+}
+
+// Filter is a compiled task filter expression.
+type Filter struct {
+	source string
+	match  matcher
+}
+
+// reports whether a task satisfies part of an expression
+type matcher func(t *task.Task) bool
+
+// kinds of fields a filter can compare
+type fieldKind int
+
+const (
+	kindInt fieldKind = iota
+	kindText
+	kindState
+	kindList
+	kindDate
+)
+
+// a task field addressable from an expression
+type field struct {
+	kind fieldKind
+	// zero means unset, so relational comparisons skip it
+	zeroUnset bool
+	num       func(t *task.Task) int
+	text      func(t *task.Task) string
+	list      func(t *task.Task) []string
+	date      func(t *task.Task) *time.Time
+}
+
+// fields by name, including short aliases
+var fields = map[string]field{
+	"id":            {kind: kindInt, num: func(t *task.Task) int { return t.ID }},
+	"pri":           {kind: kindInt, zeroUnset: true, num: func(t *task.Task) int { return t.Priority }},
+	"priority":      {kind: kindInt, zeroUnset: true, num: func(t *task.Task) int { return t.Priority }},
+	"title":         {kind: kindText, text: func(t *task.Task) string { return t.Title }},
+	"body":          {kind: kindText, text: func(t *task.Task) string { return t.Body }},
+	"text":          {kind: kindText, text: func(t *task.Task) string { return t.Title + "\n" + t.Body }},
+	"state":         {kind: kindState},
+	"tag":           {kind: kindList, list: func(t *task.Task) []string { return t.Tags }},
+	"tags":          {kind: kindList, list: func(t *task.Task) []string { return t.Tags }},
+	"ref":           {kind: kindList, list: func(t *task.Task) []string { return t.ExternalRefs }},
+	"refs":          {kind: kindList, list: func(t *task.Task) []string { return t.ExternalRefs }},
+	"due":           {kind: kindDate, date: func(t *task.Task) *time.Time { return t.Due }},
+	"created":       {kind: kindDate, date: func(t *task.Task) *time.Time { return &t.CreatedAt }},
+	"created_at":    {kind: kindDate, date: func(t *task.Task) *time.Time { return &t.CreatedAt }},
+	"updated":       {kind: kindDate, date: func(t *task.Task) *time.Time { return &t.UpdatedAt }},
+	"updated_at":    {kind: kindDate, date: func(t *task.Task) *time.Time { return &t.UpdatedAt }},
+	"started":       {kind: kindDate, date: func(t *task.Task) *time.Time { return t.StartedAt }},
+	"started_at":    {kind: kindDate, date: func(t *task.Task) *time.Time { return t.StartedAt }},
+	"completed":     {kind: kindDate, date: func(t *task.Task) *time.Time { return t.CompletedAt }},
+	"completed_at":  {kind: kindDate, date: func(t *task.Task) *time.Time { return t.CompletedAt }},
+	"external_refs": {kind: kindList, list: func(t *task.Task) []string { return t.ExternalRefs }},
+}
+
+// Parse compiles an expression such as
+//
+//	state in (todo,begun) and pri<=2 and due<+7d and not tag:later
+//
+// An empty expression matches every task.
+func Parse(input string) (*Filter, error) {
+	return ParseAt(input, time.Now())
+}
+
+// ParseAt compiles an expression with relative dates anchored at now.
+func ParseAt(input string, now time.Time) (*Filter, error) {
+	if strings.TrimSpace(input) == "" {
+		return &Filter{source: input, match: func(*task.Task) bool { return true }}, nil
+	}
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, now: now}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.start+1)
+	}
+	return &Filter{source: input, match: match}, nil
+}
+
+// Match reports whether t satisfies the filter.
+func (f *Filter) Match(t *task.Task) bool {
+	return f.match(t)
+}
+
+// String returns the source expression.
+func (f *Filter) String() string {
+	return f.source
+}
+
+// recursive descent parser over lexed tokens
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// report whether the next token is the given keyword
+func (p *parser) atKeyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, word)
+}
+
+// or := and ("or" and)*
+func (p *parser) parseOr() (matcher, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.atKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(t *task.Task) bool { return l(t) || r(t) }
+	}
+	return left, nil
+}
+
+// and := unary (["and"] unary)*
+func (p *parser) parseAnd() (matcher, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.atKeyword("and") {
+			p.next()
+		} else if tok := p.peek(); tok.kind == tokenEOF || tok.kind == tokenRParen || p.atKeyword("or") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(t *task.Task) bool { return l(t) && r(t) }
+	}
+}
+
+// unary := "not" unary | primary
+func (p *parser) parseUnary() (matcher, error) {
+	if p.atKeyword("not") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(t *task.Task) bool { return !inner(t) }, nil
+	}
+	return p.parsePrimary()
+}
+
+// primary := "(" or ")" | term
+func (p *parser) parsePrimary() (matcher, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("missing ) for ( at position %d", tok.start+1)
+		}
+		return inner, nil
+	case tokenString:
+		// a bare quoted phrase searches titles
+		return textMatcher(fields["title"], "~", tok.text)
+	case tokenWord:
+		return p.parseTerm(tok)
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.start+1)
+	}
+}
+
+// parse a comparison, presence check, or bare state name
+func (p *parser) parseTerm(tok token) (matcher, error) {
+	name := strings.ToLower(tok.text)
+	next := p.peek()
+
+	// has:due, missing:due, no:due
+	if next.kind == tokenOp && next.text == ":" && (name == "has" || name == "missing" || name == "no") {
+		p.next()
+		target := p.next()
+		if target.kind != tokenWord {
+			return nil, fmt.Errorf("expected a field name after %s: at position %d", name, target.start+1)
+		}
+		present, err := presenceMatcher(strings.ToLower(target.text))
+		if err != nil {
+			return nil, err
+		}
+		if name == "has" {
+			return present, nil
+		}
+		return func(t *task.Task) bool { return !present(t) }, nil
+	}
+
+	f, isField := fields[name]
+	if isField && next.kind == tokenOp {
+		p.next()
+		value := p.next()
+		if value.kind != tokenWord && value.kind != tokenString {
+			return nil, fmt.Errorf("expected a value after %s%s at position %d", tok.text, next.text, value.start+1)
+		}
+		m, err := p.compare(f, name, next.text, value.text)
+		if err != nil {
+			return nil, fmt.Errorf("%s%s%s: %w", tok.text, next.text, value.text, err)
+		}
+		return m, nil
+	}
+	if isField && p.atKeyword("in") {
+		p.next()
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return p.compareIn(f, name, values)
+	}
+
+	// bare words name a state or open/closed
+	switch name {
+	case "open":
+		return func(t *task.Task) bool { return !task.ActiveWorkflow().IsClosed(t.State) }, nil
+	case "closed":
+		return func(t *task.Task) bool { return task.ActiveWorkflow().IsClosed(t.State) }, nil
+	}
+	if state, ok := task.ParseState(tok.text); ok {
+		return func(t *task.Task) bool { return t.State == state }, nil
+	}
+	if isField {
+		return nil, fmt.Errorf("expected an operator after %s at position %d", tok.text, next.start+1)
+	}
+	return nil, &stateError{fmt.Sprintf("unknown field or state %q at position %d", tok.text, tok.start+1)}
+}
+
+// parse ( value, value, ... )
+func (p *parser) parseList() ([]string, error) {
+	open := p.next()
+	if open.kind != tokenLParen {
+		return nil, fmt.Errorf("expected ( after in at position %d", open.start+1)
+	}
+	values := []string{}
+	for {
+		value := p.next()
+		if value.kind != tokenWord && value.kind != tokenString {
+			return nil, fmt.Errorf("expected a value at position %d", value.start+1)
+		}
+		values = append(values, value.text)
+		sep := p.next()
+		if sep.kind == tokenRParen {
+			return values, nil
+		}
+		if sep.kind != tokenComma {
+			return nil, fmt.Errorf("expected , or ) at position %d", sep.start+1)
+		}
+	}
+}
+
+// build a matcher for field op value
+func (p *parser) compare(f field, name, op, value string) (matcher, error) {
+	switch f.kind {
+	case kindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s needs a number", name)
+		}
+		if op == ":" {
+			op = "="
+		}
+		if op == "~" || op == "!~" {
+			return nil, fmt.Errorf("%s does not support %s", name, op)
+		}
+		relational := op != "=" && op != "!="
+		return func(t *task.Task) bool {
+			got := f.num(t)
+			if f.zeroUnset && got == 0 && relational {
+				return false
+			}
+			return compareInt(got, op, n)
+		}, nil
+	case kindText:
+		return textMatcher(f, op, value)
+	case kindState:
+		state, ok := task.ParseState(value)
+		if !ok {
+			return nil, &stateError{"unknown state " + value}
+		}
+		switch op {
+		case "=", ":":
+			return func(t *task.Task) bool { return t.State == state }, nil
+		case "!=":
+			return func(t *task.Task) bool { return t.State != state }, nil
+		}
+		return nil, fmt.Errorf("state only supports =, !=, and in")
+	case kindList:
+		return listMatcher(f, op, value)
+	case kindDate:
+		if op == "~" || op == "!~" {
+			return nil, fmt.Errorf("%s does not support %s", name, op)
+		}
+		when, err := parseTimeValue(value, p.now)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *task.Task) bool {
+			ts := f.date(t)
+			if ts == nil {
+				// a missing date only satisfies "is not"
+				return op == "!="
+			}
+			return compareTime(*ts, op, when)
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported field %s", name)
+}
+
+// build a matcher for field in (values)
+func (p *parser) compareIn(f field, name string, values []string) (matcher, error) {
+	if f.kind == kindDate {
+		return nil, fmt.Errorf("%s does not support in", name)
+	}
+	matchers := make([]matcher, 0, len(values))
+	for _, value := range values {
+		m, err := p.compare(f, name, "=", value)
+		if err != nil {
+			return nil, fmt.Errorf("%s in (...): %w", name, err)
+		}
+		matchers = append(matchers, m)
+	}
+	return func(t *task.Task) bool {
+		for _, m := range matchers {
+			if m(t) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// compare integers with a relational operator
+func compareInt(a int, op string, b int) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// case-insensitive text matching: ~ and : contain, = equals
+func textMatcher(f field, op, value string) (matcher, error) {
+	needle := strings.ToLower(value)
+	switch op {
+	case "~", ":":
+		return func(t *task.Task) bool { return strings.Contains(strings.ToLower(f.text(t)), needle) }, nil
+	case "!~":
+		return func(t *task.Task) bool { return !strings.Contains(strings.ToLower(f.text(t)), needle) }, nil
+	case "=":
+		return func(t *task.Task) bool { return strings.EqualFold(f.text(t), value) }, nil
+	case "!=":
+		return func(t *task.Task) bool { return !strings.EqualFold(f.text(t), value) }, nil
+	}
+	return nil, fmt.Errorf("text fields support ~, !~, =, and !=")
+}
+
+// list matching: : and = hold the value, ~ holds one containing it
+func listMatcher(f field, op, value string) (matcher, error) {
+	has := func(t *task.Task) bool {
+		for _, item := range f.list(t) {
+			if strings.EqualFold(item, value) {
+				return true
+			}
+		}
+		return false
+	}
+	contains := func(t *task.Task) bool {
+		needle := strings.ToLower(value)
+		for _, item := range f.list(t) {
+			if strings.Contains(strings.ToLower(item), needle) {
+				return true
+			}
+		}
+		return false
+	}
+	switch op {
+	case ":", "=":
+		return has, nil
+	case "!=":
+		return func(t *task.Task) bool { return !has(t) }, nil
+	case "~":
+		return contains, nil
+	case "!~":
+		return func(t *task.Task) bool { return !contains(t) }, nil
+	}
+	return nil, fmt.Errorf("list fields support :, =, !=, ~, and !~")
+}
+
+// build a matcher for has:field
+func presenceMatcher(name string) (matcher, error) {
+	f, ok := fields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", name)
+	}
+	switch f.kind {
+	case kindInt:
+		return func(t *task.Task) bool { return f.num(t) != 0 }, nil
+	case kindText:
+		return func(t *task.Task) bool { return strings.TrimSpace(f.text(t)) != "" }, nil
+	case kindList:
+		return func(t *task.Task) bool { return len(f.list(t)) > 0 }, nil
+	case kindDate:
+		return func(t *task.Task) bool {
+			ts := f.date(t)
+			return ts != nil && !ts.IsZero()
+		}, nil
+	case kindState:
+		return func(t *task.Task) bool { return t.State != "" }, nil
+	}
+	return nil, fmt.Errorf("unsupported field %q", name)
+}
@@ -0,0 +1,96 @@
+package filter
+
+import (
+	"errors"
+	"punchlist/task"
+	"testing"
+	"time"
+)
+
+// test expression parsing and matching
+func TestFilterMatch(t *testing.T) {
+	now := time.Date(2026, 1, 10, 15, 0, 0, 0, time.UTC)
+	due := func(days int) *time.Time {
+		d := time.Date(2026, 1, 10+days, 12, 0, 0, 0, time.UTC)
+		return &d
+	}
+
+	tasks := map[string]*task.Task{
+		"launch": {ID: 1, Title: "Release notes", State: task.StateTodo, Priority: 1, Due: due(3), Tags: []string{"launch"}, CreatedAt: now.AddDate(0, 0, -20)},
+		"later":  {ID: 2, Title: "Refactor parser", State: task.StateBegun, Priority: 2, Due: due(30), Tags: []string{"launch", "later"}, CreatedAt: now.AddDate(0, 0, -2)},
+		"done":   {ID: 3, Title: "Ship release", State: task.StateDone, Priority: 3, Tags: []string{"ops"}, CreatedAt: now.AddDate(0, 0, -1)},
+		"nodue":  {ID: 4, Title: "Write docs", State: task.StateTodo, CreatedAt: now},
+	}
+
+	cases := []struct {
+		expr string
+		want []string
+	}{
+		{"", []string{"launch", "later", "done", "nodue"}},
+		{"todo", []string{"launch", "nodue"}},
+		{"state in (todo,begun) and pri<=2", []string{"launch", "later"}},
+		{"due<+7d", []string{"launch"}},
+		{"due<=2026-01-13", []string{"launch"}},
+		{"due=2026-01-13", []string{"launch"}},
+		{"tag:launch and not tag:later", []string{"launch"}},
+		{`title~"release"`, []string{"launch", "done"}},
+		{"missing:due", []string{"done", "nodue"}},
+		{"has:due and (pri=2 or pri=1)", []string{"launch", "later"}},
+		{"created>-7d", []string{"later", "done", "nodue"}},
+		{"open and not has:tags", []string{"nodue"}},
+		{"closed", []string{"done"}},
+		{"id in (1, 4)", []string{"launch", "nodue"}},
+		{`"docs"`, []string{"nodue"}},
+		{"tag=ops or state=begun", []string{"later", "done"}},
+	}
+
+	for _, tc := range cases {
+		f, err := ParseAt(tc.expr, now)
+		if err != nil {
+			t.Errorf("ParseAt(%q) failed: %v", tc.expr, err)
+			continue
+		}
+		want := map[string]bool{}
+		for _, name := range tc.want {
+			want[name] = true
+		}
+		for name, tk := range tasks {
+			if got := f.Match(tk); got != want[name] {
+				t.Errorf("%q on %s: expected %v, got %v", tc.expr, name, want[name], got)
+			}
+		}
+	}
+}
+
+// test that malformed expressions are rejected
+func TestFilterErrors(t *testing.T) {
+	for _, expr := range []string{
+		"pri<=",
+		"state in (todo",
+		"bogus",
+		"pri~2",
+		"state=limbo",
+		"due<someday",
+		`title~"open`,
+		"(todo",
+		"todo)",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected an error for %q", expr)
+		}
+	}
+}
+
+// test that unknown states are told apart from malformed expressions
+func TestFilterUnknownState(t *testing.T) {
+	for _, expr := range []string{"state=limbo", "state in (todo,limbo)", "limbo", "open and not limbo"} {
+		if _, err := Parse(expr); !errors.Is(err, ErrUnknownState) {
+			t.Errorf("Expected an unknown state error for %q, got %v", expr, err)
+		}
+	}
+	for _, expr := range []string{"pri<=", "pri~2", "due<someday", "(todo"} {
+		if _, err := Parse(expr); err == nil || errors.Is(err, ErrUnknownState) {
+			t.Errorf("Expected a syntax error for %q, got %v", expr, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// kinds of lexical tokens
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// a lexical token with its position for error messages
+type token struct {
+	kind  tokenKind
+	text  string
+	start int
+}
+
+// comparison operators, longest first so <= wins over <
+var operators = []string{"!=", "<=", ">=", "!~", "=", "<", ">", "~", ":"}
+
+// split an expression into tokens
+func lex(input string) ([]token, error) {
+	tokens := []token{}
+	i := 0
+	for i < len(input) {
+		r := rune(input[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", start: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", start: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", start: i})
+			i++
+		case r == '"' || r == '\'':
+			text, next, err := lexString(input, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, start: i})
+			i = next
+		default:
+			if op := matchOperator(input[i:]); op != "" {
+				tokens = append(tokens, token{kind: tokenOp, text: op, start: i})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(input) && isWordByte(input[i]) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("unexpected %q at position %d", input[i], i+1)
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: input[start:i], start: start})
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, start: len(input)})
+	return tokens, nil
+}
+
+// read a quoted string, allowing backslash escapes
+func lexString(input string, start int) (string, int, error) {
+	quote := input[start]
+	var b strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		c := input[i]
+		if c == '\\' && i+1 < len(input) {
+			i++
+			b.WriteByte(input[i])
+			continue
+		}
+		if c == quote {
+			return b.String(), i + 1, nil
+		}
+		b.WriteByte(c)
+	}
+	return "", 0, fmt.Errorf("unterminated string starting at position %d", start+1)
+}
+
+// match an operator at the start of s
+func matchOperator(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// bytes allowed in bare words such as names, numbers, and dates
+func isWordByte(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	switch c {
+	case '_', '-', '+', '.', '/', '@', '#':
+		return true
+	}
+	return c >= 0x80
+}
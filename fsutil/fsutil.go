@@ -0,0 +1,182 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// marker placed between the target name and the random suffix of temp files
+const tempMarker = ".tmp-"
+
+// OrphanAge is how old a temp file must be before recovery treats it as
+// abandoned rather than belonging to a write still in progress.
+const OrphanAge = time.Minute
+
+// Orphan describes a temp file left behind by an interrupted write.
+type Orphan struct {
+	Path         string
+	Target       string
+	TargetExists bool
+}
+
+// called with each path about to change, when set
+var changeHook func(path string)
+
+// SetChangeHook has WriteFile, Rename and Remove call fn with each path they
+// are about to change, before changing it, until the hook is set again; nil
+// removes it.
+func SetChangeHook(fn func(path string)) {
+	changeHook = fn
+}
+
+// tell the change hook a path is about to change
+func notifyChange(path string) {
+	if changeHook != nil {
+		changeHook(path)
+	}
+}
+
+// Rename renames oldPath to newPath, telling the change hook about both.
+func Rename(oldPath, newPath string) error {
+	notifyChange(oldPath)
+	notifyChange(newPath)
+	return os.Rename(oldPath, newPath)
+}
+
+// Remove removes path, telling the change hook first.
+func Remove(path string) error {
+	notifyChange(path)
+	return os.Remove(path)
+}
+
+// WriteFile writes data to a temp file beside path, syncs it, and renames
+// it over path so readers see either the old or the new contents. An
+// existing file keeps its permissions; new files get perm.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	notifyChange(path)
+	dir := filepath.Dir(path)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+tempMarker+"*")
+	if err != nil {
+		return fmt.Errorf("could not create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	// clean up the temp file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("could not write temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("could not set permissions on temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("could not sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not close temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("could not replace %s: %w", filepath.Base(path), err)
+	}
+	committed = true
+
+	syncDir(dir)
+	return nil
+}
+
+// flush a directory entry change to disk where the platform allows it
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	_ = d.Sync()
+}
+
+// IsTempFile reports whether name looks like a WriteFile temp file.
+func IsTempFile(name string) bool {
+	_, ok := tempTarget(name)
+	return ok
+}
+
+// map a temp file name back to the file it was meant to replace
+func tempTarget(name string) (string, bool) {
+	if !strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	idx := strings.LastIndex(name, tempMarker)
+	if idx <= 1 {
+		return "", false
+	}
+	return name[1:idx], true
+}
+
+// FindOrphans lists temp files in dir older than OrphanAge.
+func FindOrphans(dir string) ([]Orphan, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-OrphanAge)
+	orphans := []Orphan{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		target, ok := tempTarget(entry.Name())
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		targetPath := filepath.Join(dir, target)
+		_, statErr := os.Stat(targetPath)
+		orphans = append(orphans, Orphan{
+			Path:         filepath.Join(dir, entry.Name()),
+			Target:       targetPath,
+			TargetExists: statErr == nil,
+		})
+	}
+	return orphans, nil
+}
+
+// RecoverDir removes orphaned temp files whose target is intact and
+// returns the ones left in place because the target is missing.
+func RecoverDir(dir string) ([]Orphan, error) {
+	orphans, err := FindOrphans(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := []Orphan{}
+	for _, orphan := range orphans {
+		if !orphan.TargetExists {
+			kept = append(kept, orphan)
+			continue
+		}
+		if err := os.Remove(orphan.Path); err != nil && !os.IsNotExist(err) {
+			return kept, fmt.Errorf("could not remove %s: %w", orphan.Path, err)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,133 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// test atomic writes and orphan recovery
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "001-task.md")
+
+	t.Run("creates and replaces a file", func(t *testing.T) {
+		if err := WriteFile(path, []byte("first\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := WriteFile(path, []byte("second\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		if string(content) != "second\n" {
+			t.Errorf("Expected replaced contents, got %q", content)
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("Failed to read dir: %v", err)
+		}
+		for _, entry := range entries {
+			if IsTempFile(entry.Name()) {
+				t.Errorf("Temp file %s was left behind", entry.Name())
+			}
+		}
+	})
+
+	t.Run("keeps existing permissions", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("permission bits are not preserved on windows")
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			t.Fatalf("Failed to chmod: %v", err)
+		}
+		if err := WriteFile(path, []byte("third\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat file: %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+		}
+	})
+
+	t.Run("recovers orphaned temp files", func(t *testing.T) {
+		stale := filepath.Join(dir, ".001-task.md.tmp-123")
+		missing := filepath.Join(dir, ".002-new.md.tmp-456")
+		fresh := filepath.Join(dir, ".001-task.md.tmp-789")
+		old := time.Now().Add(-2 * OrphanAge)
+		for _, p := range []string{stale, missing, fresh} {
+			if err := os.WriteFile(p, []byte("partial"), 0644); err != nil {
+				t.Fatalf("Failed to write temp file: %v", err)
+			}
+		}
+		os.Chtimes(stale, old, old)
+		os.Chtimes(missing, old, old)
+
+		kept, err := RecoverDir(dir)
+		if err != nil {
+			t.Fatalf("RecoverDir failed: %v", err)
+		}
+		if _, err := os.Stat(stale); !os.IsNotExist(err) {
+			t.Errorf("Expected stale temp file to be removed")
+		}
+		if _, err := os.Stat(fresh); err != nil {
+			t.Errorf("Expected in-progress temp file to be left alone")
+		}
+		if len(kept) != 1 || kept[0].Path != missing {
+			t.Errorf("Expected only %s to be kept, got %v", missing, kept)
+		}
+	})
+
+	t.Run("tells the change hook before changing a file", func(t *testing.T) {
+		hooked := filepath.Join(dir, "003-hooked.md")
+		moved := filepath.Join(dir, "004-moved.md")
+		seen := []string{}
+		SetChangeHook(func(path string) {
+			_, err := os.Stat(path)
+			seen = append(seen, fmt.Sprintf("%s %v", filepath.Base(path), err == nil))
+		})
+		defer SetChangeHook(nil)
+
+		WriteFile(hooked, []byte("new"), 0644)
+		Rename(hooked, moved)
+		Remove(moved)
+		want := "003-hooked.md false, 003-hooked.md true, 004-moved.md false, 004-moved.md true"
+		if got := strings.Join(seen, ", "); got != want {
+			t.Errorf("Expected the hook to see %q, got %q", want, got)
+		}
+	})
+}
+
+// test that a held lock blocks a second locker until released
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	first, err := LockFile(path, time.Second)
+	if err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+
+	if _, err := LockFile(path, 100*time.Millisecond); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("Expected ErrLockTimeout while lock is held, got %v", err)
+	}
+
+	if err := first.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	second, err := LockFile(path, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected lock after release, got %v", err)
+	}
+	second.Unlock()
+}
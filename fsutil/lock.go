@@ -0,0 +1,81 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLockTimeout indicates another process held a lock past the timeout.
+var ErrLockTimeout = errors.New("timed out waiting for lock")
+
+// how often a blocked Lock call retries
+const lockPollInterval = 50 * time.Millisecond
+
+// Lock is an advisory lock held on a file until Unlock is called.
+type Lock struct {
+	file *os.File
+}
+
+// LockFile takes an exclusive advisory lock on path, creating it if needed,
+// and waits up to timeout for another holder to release it. The holder's
+// pid is written into the file so a blocked caller can report it.
+func LockFile(path string, timeout time.Duration) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not lock %s: %w", path, err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			holder := readLockHolder(path)
+			f.Close()
+			if holder != "" {
+				return nil, fmt.Errorf("%w after %s (held by pid %s)", ErrLockTimeout, timeout, holder)
+			}
+			return nil, fmt.Errorf("%w after %s", ErrLockTimeout, timeout)
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	// record the holder for anyone waiting on us
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{file: f}, nil
+}
+
+// Unlock releases the lock and closes the lock file.
+func (l *Lock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	unlockErr := unlock(l.file)
+	closeErr := l.file.Close()
+	l.file = nil
+	if unlockErr != nil {
+		return unlockErr
+	}
+	return closeErr
+}
+
+// read the pid recorded by the current lock holder
+func readLockHolder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
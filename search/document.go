@@ -0,0 +1,76 @@
+package search
+
+import (
+	"punchlist/task"
+	"strings"
+)
+
+// searchable fields of a task
+const (
+	FieldTitle = "title"
+	FieldTags  = "tags"
+	FieldBody  = "body"
+	FieldNotes = "notes"
+	FieldLog   = "log"
+)
+
+// fields in the order snippets prefer them
+var allFields = []string{FieldTitle, FieldTags, FieldBody, FieldNotes, FieldLog}
+
+// how much a match in each field counts towards relevance
+var fieldWeights = map[string]float64{
+	FieldTitle: 3,
+	FieldTags:  2,
+	FieldBody:  1,
+	FieldNotes: 1,
+	FieldLog:   0.5,
+}
+
+// Document is the searchable text of one task.
+type Document struct {
+	ID     int
+	Title  string
+	State  string
+	Fields map[string]string
+}
+
+// FromTask splits a task into searchable fields: the title, tags, and the
+// body with its Notes and Log sections apart from the rest.
+func FromTask(t *task.Task) Document {
+	doc := Document{
+		ID:    t.ID,
+		Title: t.Title,
+		State: string(t.State),
+		Fields: map[string]string{
+			FieldTitle: t.Title,
+			FieldTags:  strings.Join(t.Tags, " "),
+		},
+	}
+
+	sections := map[string][]string{}
+	current := FieldBody
+	for i, line := range strings.Split(t.Body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		// the leading heading repeats the title
+		if i == 0 && strings.HasPrefix(trimmed, "# ") && strings.TrimSpace(trimmed[2:]) == t.Title {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "## ") {
+			switch strings.ToLower(strings.TrimSpace(trimmed[3:])) {
+			case "notes":
+				current = FieldNotes
+				continue
+			case "log":
+				current = FieldLog
+				continue
+			default:
+				current = FieldBody
+			}
+		}
+		sections[current] = append(sections[current], line)
+	}
+	for field, lines := range sections {
+		doc.Fields[field] = strings.TrimSpace(strings.Join(lines, "\n"))
+	}
+	return doc
+}
@@ -0,0 +1,361 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"punchlist/fsutil"
+	"sort"
+	"time"
+)
+
+// bump when the index layout changes so old files are rebuilt
+const indexVersion = 1
+
+// bm25 tuning
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Index is an inverted index from words to the tasks that contain them.
+type Index struct {
+	Version int                  `json:"version"`
+	Docs    []*DocInfo           `json:"docs"`
+	Terms   map[string][]Posting `json:"terms"`
+
+	byPath map[string]int
+	dirty  bool
+}
+
+// DocInfo describes one indexed file; removed files leave a nil slot until
+// the index is saved.
+type DocInfo struct {
+	Path    string         `json:"path"`
+	ModTime int64          `json:"mtime"`
+	Size    int64          `json:"size"`
+	ID      int            `json:"id"`
+	Lengths map[string]int `json:"lengths"`
+}
+
+// Posting records how often a word appears in one field of one document.
+type Posting struct {
+	Doc   int    `json:"d"`
+	Field string `json:"f"`
+	Count int    `json:"n"`
+}
+
+// File is a task file to index; Path is the key handed back to the Loader.
+type File struct {
+	Path    string
+	ModTime time.Time
+	Size    int64
+}
+
+// Loader reads the document stored at an indexed path.
+type Loader func(path string) (Document, error)
+
+// Result is one matching task.
+type Result struct {
+	Path    string
+	Score   float64
+	Doc     Document
+	Snippet Snippet
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{Version: indexVersion, Terms: map[string][]Posting{}, byPath: map[string]int{}}
+}
+
+// LoadIndex reads a saved index. A missing, unreadable or outdated file
+// gives an empty index that will be rebuilt on the next Update.
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewIndex(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ix := NewIndex()
+	if err := json.Unmarshal(data, ix); err != nil || ix.Version != indexVersion {
+		ix = NewIndex()
+		ix.dirty = true
+		return ix, nil
+	}
+	if ix.Terms == nil {
+		ix.Terms = map[string][]Posting{}
+	}
+	for i, doc := range ix.Docs {
+		if doc != nil {
+			ix.byPath[doc.Path] = i
+		}
+	}
+	return ix, nil
+}
+
+// Save writes the index atomically, dropping slots of removed files.
+func (ix *Index) Save(path string) error {
+	ix.compact()
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	ix.dirty = false
+	return nil
+}
+
+// Changed reports whether Update changed the index since it was loaded or saved.
+func (ix *Index) Changed() bool {
+	return ix.dirty
+}
+
+// Update brings the index in line with files, reading any that are new or
+// changed and dropping any that are gone. Files the loader can't read are
+// left out and their errors returned.
+func (ix *Index) Update(files []File, load Loader) []error {
+	seen := make(map[string]bool, len(files))
+	stale := map[int]bool{}
+	pending := []File{}
+	for _, file := range files {
+		seen[file.Path] = true
+		i, ok := ix.byPath[file.Path]
+		if ok {
+			doc := ix.Docs[i]
+			if doc.ModTime == file.ModTime.UnixNano() && doc.Size == file.Size {
+				continue
+			}
+			stale[i] = true
+		}
+		pending = append(pending, file)
+	}
+	for path, i := range ix.byPath {
+		if !seen[path] {
+			stale[i] = true
+		}
+	}
+
+	if len(stale) > 0 {
+		ix.removeDocs(stale)
+	}
+	var errs []error
+	for _, file := range pending {
+		doc, err := load(file.Path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ix.addDoc(file, doc)
+	}
+	return errs
+}
+
+// drop documents and their postings
+func (ix *Index) removeDocs(docs map[int]bool) {
+	for i := range docs {
+		delete(ix.byPath, ix.Docs[i].Path)
+		ix.Docs[i] = nil
+	}
+	for term, postings := range ix.Terms {
+		kept := postings[:0]
+		for _, p := range postings {
+			if !docs[p.Doc] {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == 0 {
+			delete(ix.Terms, term)
+		} else {
+			ix.Terms[term] = kept
+		}
+	}
+	ix.dirty = true
+}
+
+// add a document's words to the index
+func (ix *Index) addDoc(file File, doc Document) {
+	i := len(ix.Docs)
+	info := &DocInfo{
+		Path:    file.Path,
+		ModTime: file.ModTime.UnixNano(),
+		Size:    file.Size,
+		ID:      doc.ID,
+		Lengths: map[string]int{},
+	}
+	ix.Docs = append(ix.Docs, info)
+	ix.byPath[file.Path] = i
+
+	for _, field := range allFields {
+		counts := map[string]int{}
+		words := terms(doc.Fields[field])
+		for _, term := range words {
+			counts[term]++
+		}
+		if len(words) > 0 {
+			info.Lengths[field] = len(words)
+		}
+		for term, count := range counts {
+			ix.Terms[term] = append(ix.Terms[term], Posting{Doc: i, Field: field, Count: count})
+		}
+	}
+	ix.dirty = true
+}
+
+// renumber documents to close the gaps left by removed files
+func (ix *Index) compact() {
+	renumber := make(map[int]int, len(ix.Docs))
+	docs := make([]*DocInfo, 0, len(ix.Docs))
+	for i, doc := range ix.Docs {
+		if doc == nil {
+			continue
+		}
+		renumber[i] = len(docs)
+		ix.byPath[doc.Path] = len(docs)
+		docs = append(docs, doc)
+	}
+	if len(docs) == len(ix.Docs) {
+		return
+	}
+	ix.Docs = docs
+	for _, postings := range ix.Terms {
+		for j := range postings {
+			postings[j].Doc = renumber[postings[j].Doc]
+		}
+	}
+}
+
+// Search finds documents matching q, best first. It narrows candidates with
+// the index, then loads each one to check phrases and exclusions and to build
+// a snippet. A limit of zero or less returns every match.
+func (ix *Index) Search(q *Query, load Loader, limit int) []Result {
+	live := 0
+	totals := map[string]int{}
+	for _, doc := range ix.Docs {
+		if doc == nil {
+			continue
+		}
+		live++
+		for field, n := range doc.Lengths {
+			totals[field] += n
+		}
+	}
+	if live == 0 {
+		return nil
+	}
+
+	var candidates map[int]bool
+	scores := map[int]float64{}
+	for _, clause := range q.Clauses {
+		if clause.Negate {
+			continue
+		}
+		matched := ix.clauseDocs(clause)
+		if candidates == nil {
+			candidates = matched
+		} else {
+			for doc := range candidates {
+				if !matched[doc] {
+					delete(candidates, doc)
+				}
+			}
+		}
+		for _, term := range clause.Terms {
+			ix.scoreTerm(term, clause, live, totals, scores)
+		}
+	}
+	// single-word exclusions can be applied from the index alone
+	for _, clause := range q.Clauses {
+		if clause.Negate && len(clause.Terms) == 1 {
+			for doc := range ix.clauseDocs(clause) {
+				delete(candidates, doc)
+			}
+		}
+	}
+
+	ranked := make([]int, 0, len(candidates))
+	for doc := range candidates {
+		ranked = append(ranked, doc)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return ix.Docs[a].ID < ix.Docs[b].ID
+	})
+
+	results := []Result{}
+	for _, i := range ranked {
+		info := ix.Docs[i]
+		doc, err := load(info.Path)
+		if err != nil || !q.Match(doc) {
+			continue
+		}
+		results = append(results, Result{
+			Path:    info.Path,
+			Score:   scores[i],
+			Doc:     doc,
+			Snippet: MakeSnippet(doc, q, defaultSnippetWidth),
+		})
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+	}
+	return results
+}
+
+// documents containing every word of a clause in one of its fields
+func (ix *Index) clauseDocs(clause Clause) map[int]bool {
+	var docs map[int]bool
+	scope := map[string]bool{}
+	for _, field := range clause.fields() {
+		scope[field] = true
+	}
+	for _, term := range clause.Terms {
+		found := map[int]bool{}
+		for _, p := range ix.Terms[term] {
+			if scope[p.Field] && (docs == nil || docs[p.Doc]) {
+				found[p.Doc] = true
+			}
+		}
+		docs = found
+	}
+	return docs
+}
+
+// add a word's bm25f contribution to each document's score
+func (ix *Index) scoreTerm(term string, clause Clause, live int, totals map[string]int, scores map[int]float64) {
+	postings := ix.Terms[term]
+	docFreq := map[int]bool{}
+	for _, p := range postings {
+		docFreq[p.Doc] = true
+	}
+	df := float64(len(docFreq))
+	idf := math.Log(1 + (float64(live)-df+0.5)/(df+0.5))
+
+	scope := map[string]bool{}
+	for _, field := range clause.fields() {
+		scope[field] = true
+	}
+	weighted := map[int]float64{}
+	for _, p := range postings {
+		if !scope[p.Field] {
+			continue
+		}
+		avg := float64(totals[p.Field]) / float64(live)
+		length := float64(ix.Docs[p.Doc].Lengths[p.Field])
+		norm := 1 - bm25B
+		if avg > 0 {
+			norm += bm25B * length / avg
+		}
+		weighted[p.Doc] += fieldWeights[p.Field] * float64(p.Count) / norm
+	}
+	for doc, tf := range weighted {
+		scores[doc] += idf * tf / (bm25K1 + tf)
+	}
+}
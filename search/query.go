@@ -0,0 +1,160 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// field names accepted before a colon in a query
+var fieldNames = map[string]string{
+	"title": FieldTitle,
+	"tag":   FieldTags,
+	"tags":  FieldTags,
+	"body":  FieldBody,
+	"note":  FieldNotes,
+	"notes": FieldNotes,
+	"log":   FieldLog,
+	"logs":  FieldLog,
+}
+
+// Clause is one word or phrase in a query.
+type Clause struct {
+	// Field limits the clause to one field; empty means any field
+	Field string
+	// Terms holds one word, or several for a phrase
+	Terms []string
+	// Negate excludes tasks that match
+	Negate bool
+}
+
+// Query is a parsed search; every clause must hold.
+type Query struct {
+	Clauses []Clause
+}
+
+// ParseQuery reads words, "quoted phrases", field:word or field:"phrase",
+// and -word or -"phrase" to exclude.
+func ParseQuery(input string) (*Query, error) {
+	q := &Query{}
+	i := 0
+	for i < len(input) {
+		if input[i] == ' ' || input[i] == '\t' || input[i] == '\n' {
+			i++
+			continue
+		}
+
+		clause := Clause{}
+		if input[i] == '-' && i+1 < len(input) && input[i+1] != ' ' {
+			clause.Negate = true
+			i++
+		}
+
+		// field prefix, only for known field names
+		if colon := strings.IndexByte(input[i:], ':'); colon > 0 {
+			name := strings.ToLower(input[i : i+colon])
+			if field, ok := fieldNames[name]; ok {
+				clause.Field = field
+				i += colon + 1
+			}
+		}
+
+		var text string
+		if i < len(input) && input[i] == '"' {
+			end := strings.IndexByte(input[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote at position %d", i+1)
+			}
+			text = input[i+1 : i+1+end]
+			i += end + 2
+		} else {
+			end := strings.IndexAny(input[i:], " \t\n")
+			if end < 0 {
+				end = len(input) - i
+			}
+			text = input[i : i+end]
+			i += end
+		}
+
+		clause.Terms = terms(text)
+		if len(clause.Terms) > 0 {
+			q.Clauses = append(q.Clauses, clause)
+		}
+	}
+
+	for _, clause := range q.Clauses {
+		if !clause.Negate {
+			return q, nil
+		}
+	}
+	return nil, fmt.Errorf("search needs at least one word to look for")
+}
+
+// String renders the query back in its input syntax.
+func (q *Query) String() string {
+	parts := make([]string, 0, len(q.Clauses))
+	for _, clause := range q.Clauses {
+		part := strings.Join(clause.Terms, " ")
+		if len(clause.Terms) > 1 {
+			part = `"` + part + `"`
+		}
+		if clause.Field != "" {
+			part = clause.Field + ":" + part
+		}
+		if clause.Negate {
+			part = "-" + part
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// fields a clause looks in
+func (c Clause) fields() []string {
+	if c.Field != "" {
+		return []string{c.Field}
+	}
+	return allFields
+}
+
+// report whether the clause matches text from one field
+func (c Clause) matchesText(text string) bool {
+	return len(c.positions(tokenize(text))) > 0
+}
+
+// indexes of tokens where the clause's words start in order
+func (c Clause) positions(tokens []token) []int {
+	found := []int{}
+	for i := 0; i+len(c.Terms) <= len(tokens); i++ {
+		match := true
+		for j, term := range c.Terms {
+			if tokens[i+j].term != term {
+				match = false
+				break
+			}
+		}
+		if match {
+			found = append(found, i)
+		}
+	}
+	return found
+}
+
+// report whether a document satisfies the clause, ignoring negation
+func (c Clause) matches(doc Document) bool {
+	for _, field := range c.fields() {
+		if c.matchesText(doc.Fields[field]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a document satisfies every clause.
+func (q *Query) Match(doc Document) bool {
+	for _, clause := range q.Clauses {
+		if clause.matches(doc) == clause.Negate {
+			return false
+		}
+	}
+	return true
+}
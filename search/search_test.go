@@ -0,0 +1,186 @@
+package search
+
+import (
+	"fmt"
+	"path/filepath"
+	"punchlist/task"
+	"strings"
+	"testing"
+	"time"
+)
+
+// build an index over tasks keyed by "<id>.md"
+func buildIndex(t *testing.T, tasks []*task.Task) (*Index, Loader) {
+	t.Helper()
+	docs := map[string]Document{}
+	files := []File{}
+	for _, tk := range tasks {
+		path := fmt.Sprintf("%d.md", tk.ID)
+		docs[path] = FromTask(tk)
+		files = append(files, File{Path: path, ModTime: time.Unix(int64(tk.ID), 0), Size: int64(len(tk.Body))})
+	}
+	load := func(path string) (Document, error) {
+		doc, ok := docs[path]
+		if !ok {
+			return Document{}, fmt.Errorf("missing %s", path)
+		}
+		return doc, nil
+	}
+	ix := NewIndex()
+	if errs := ix.Update(files, load); len(errs) > 0 {
+		t.Fatalf("Update: %v", errs)
+	}
+	return ix, load
+}
+
+// ids of results in order
+func resultIDs(results []Result) string {
+	ids := []string{}
+	for _, r := range results {
+		ids = append(ids, fmt.Sprint(r.Doc.ID))
+	}
+	return strings.Join(ids, ",")
+}
+
+var sampleTasks = []*task.Task{
+	{ID: 1, Title: "Release checklist", Body: "# Release checklist\n\nGo through the list."},
+	{ID: 2, Title: "Write docs", Body: "# Write docs\n\nMention the release notes and the release date.\n\n## Notes\n\n- ask about staging\n\n## Log\n\n- 2026-01-02T10:00:00Z: deployed to staging"},
+	{ID: 3, Title: "Fix login", Tags: []string{"release"}, Body: "# Fix login\n\nNotes on the release of login."},
+	{ID: 4, Title: "Plan party", Body: "# Plan party\n\nNothing about shipping."},
+}
+
+// test query parsing
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`release "Release Notes" title:launch -staging log:"deployed to" http://x.io`)
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+	expected := `release "release notes" title:launch -staging log:"deployed to" "http x io"`
+	if q.String() != expected {
+		t.Errorf("got %q, want %q", q.String(), expected)
+	}
+
+	for _, input := range []string{`"open`, `-later`, `   `} {
+		if _, err := ParseQuery(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+// test ranking, phrases, fields and exclusions
+func TestSearch(t *testing.T) {
+	ix, load := buildIndex(t, sampleTasks)
+
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{"release", "1,3,2"},
+		{`"release notes"`, "2"},
+		{"title:release", "1"},
+		{"tag:release", "3"},
+		{"notes:staging", "2"},
+		{"log:deployed", "2"},
+		{"release -staging", "1,3"},
+		{`release -"release notes"`, "1,3"},
+		{"shipping", "4"},
+		{"missing", ""},
+	}
+	for _, c := range cases {
+		q, err := ParseQuery(c.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", c.query, err)
+		}
+		if got := resultIDs(ix.Search(q, load, 0)); got != c.expected {
+			t.Errorf("search %q: got %s, want %s", c.query, got, c.expected)
+		}
+	}
+
+	q, _ := ParseQuery("release")
+	if got := resultIDs(ix.Search(q, load, 2)); got != "1,3" {
+		t.Errorf("expected limit to keep the top results, got %s", got)
+	}
+}
+
+// test snippets and highlight ranges
+func TestSnippet(t *testing.T) {
+	doc := FromTask(sampleTasks[1])
+	q, _ := ParseQuery(`"release notes"`)
+	snippet := MakeSnippet(doc, q, 80)
+	if snippet.Field != FieldBody {
+		t.Fatalf("expected body snippet, got %s", snippet.Field)
+	}
+	if len(snippet.Highlights) != 1 {
+		t.Fatalf("expected one highlight, got %v", snippet.Highlights)
+	}
+	h := snippet.Highlights[0]
+	if snippet.Text[h[0]:h[1]] != "release notes" {
+		t.Errorf("highlight covers %q", snippet.Text[h[0]:h[1]])
+	}
+
+	long := &task.Task{ID: 9, Title: "Long", Body: strings.Repeat("filler words here ", 20) + "needle " + strings.Repeat("more text after ", 20)}
+	q, _ = ParseQuery("needle")
+	snippet = MakeSnippet(FromTask(long), q, 40)
+	if !strings.HasPrefix(snippet.Text, "…") || !strings.HasSuffix(snippet.Text, "…") {
+		t.Errorf("expected a cut snippet, got %q", snippet.Text)
+	}
+	h = snippet.Highlights[0]
+	if snippet.Text[h[0]:h[1]] != "needle" {
+		t.Errorf("highlight covers %q", snippet.Text[h[0]:h[1]])
+	}
+}
+
+// test saving, reloading and updating an index
+func TestIndexUpdate(t *testing.T) {
+	ix, load := buildIndex(t, sampleTasks)
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := ix.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	files := []File{}
+	for _, doc := range ix.Docs {
+		files = append(files, File{Path: doc.Path, ModTime: time.Unix(0, doc.ModTime), Size: doc.Size})
+	}
+	loaded.Update(files, func(path string) (Document, error) {
+		t.Fatalf("unchanged file %s was re-read", path)
+		return Document{}, nil
+	})
+	if loaded.Changed() {
+		t.Errorf("expected an unchanged index")
+	}
+
+	// drop task 1 and change task 4
+	files = files[1:]
+	files[2].ModTime = time.Unix(100, 0)
+	changed := *sampleTasks[3]
+	changed.Body = "# Plan party\n\nRelease the balloons."
+	reload := func(path string) (Document, error) {
+		if path == "4.md" {
+			return FromTask(&changed), nil
+		}
+		return load(path)
+	}
+	loaded.Update(files, reload)
+	if !loaded.Changed() {
+		t.Errorf("expected a changed index")
+	}
+	q, _ := ParseQuery("release")
+	if got := resultIDs(loaded.Search(q, reload, 0)); got != "3,2,4" && got != "3,4,2" {
+		t.Errorf("unexpected results after update: %s", got)
+	}
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if len(loaded.Docs) != 3 {
+		t.Errorf("expected removed docs to be compacted, got %d", len(loaded.Docs))
+	}
+	again, _ := LoadIndex(path)
+	if got := resultIDs(again.Search(q, reload, 0)); got != resultIDs(loaded.Search(q, reload, 0)) {
+		t.Errorf("reloaded index gives %s", got)
+	}
+}
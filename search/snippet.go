@@ -0,0 +1,120 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// runes of context shown around a match
+const defaultSnippetWidth = 80
+
+// fields snippets prefer; the title is printed with every result anyway
+var snippetFields = []string{FieldBody, FieldNotes, FieldLog, FieldTags, FieldTitle}
+
+// Snippet is a short excerpt of one field around the first match.
+type Snippet struct {
+	Field string
+	Text  string
+	// Highlights are byte ranges of matches within Text
+	Highlights [][2]int
+}
+
+// MakeSnippet picks the first field with a match and cuts a window of about
+// width runes around it, with whitespace collapsed.
+func MakeSnippet(doc Document, q *Query, width int) Snippet {
+	for _, field := range snippetFields {
+		text := strings.Join(strings.Fields(doc.Fields[field]), " ")
+		ranges := Highlight(text, q, field)
+		if len(ranges) == 0 {
+			continue
+		}
+		return cutSnippet(field, text, ranges, width)
+	}
+	return Snippet{Field: FieldTitle, Text: doc.Title}
+}
+
+// Highlight returns the byte ranges in text, from the given field, that
+// match the query's words and phrases.
+func Highlight(text string, q *Query, field string) [][2]int {
+	tokens := tokenize(text)
+	ranges := [][2]int{}
+	for _, clause := range q.Clauses {
+		if clause.Negate || (clause.Field != "" && clause.Field != field) {
+			continue
+		}
+		for _, pos := range clause.positions(tokens) {
+			last := tokens[pos+len(clause.Terms)-1]
+			ranges = append(ranges, [2]int{tokens[pos].start, last.end})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	// merge overlapping matches
+	merged := [][2]int{}
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0] <= merged[n-1][1] {
+			if r[1] > merged[n-1][1] {
+				merged[n-1][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// cut a window around the first match, breaking at spaces
+func cutSnippet(field, text string, ranges [][2]int, width int) Snippet {
+	first := ranges[0]
+	start := backRunes(text, first[0], width/3)
+	if start > 0 {
+		if space := strings.IndexByte(text[start:first[0]], ' '); space >= 0 {
+			start += space + 1
+		}
+	}
+	end := forwardRunes(text, start, width)
+	if end < first[1] {
+		end = first[1]
+	}
+	if end < len(text) {
+		if space := strings.LastIndexByte(text[first[1]:end], ' '); space >= 0 {
+			end = first[1] + space
+		}
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "…"
+	}
+	if end < len(text) {
+		suffix = "…"
+	}
+	snippet := Snippet{Field: field, Text: prefix + text[start:end] + suffix}
+	for _, r := range ranges {
+		if r[0] < start || r[1] > end {
+			continue
+		}
+		shift := len(prefix) - start
+		snippet.Highlights = append(snippet.Highlights, [2]int{r[0] + shift, r[1] + shift})
+	}
+	return snippet
+}
+
+// step back n runes from a byte offset
+func backRunes(s string, pos, n int) int {
+	for ; n > 0 && pos > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+// step forward n runes from a byte offset
+func forwardRunes(s string, pos, n int) int {
+	for ; n > 0 && pos < len(s); n-- {
+		_, size := utf8.DecodeRuneInString(s[pos:])
+		pos += size
+	}
+	return pos
+}
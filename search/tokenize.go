@@ -0,0 +1,45 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// a lower-cased word and its byte range in the original text
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// split text into lower-cased words of letters and digits
+func tokenize(text string) []token {
+	tokens := []token{}
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// the terms of text, without positions
+func terms(text string) []string {
+	tokens := tokenize(text)
+	out := make([]string, 0, len(tokens))
+	for _, tok := range tokens {
+		out = append(out, tok.term)
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package task
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// known frontmatter key and the Task field that holds it
+type frontmatterField struct {
+	key   string
+	index int
+}
+
+// list Task fields that map to frontmatter keys, in declaration order
+func frontmatterFields() []frontmatterField {
+	taskType := reflect.TypeOf(Task{})
+	fields := []frontmatterField{}
+	for i := 0; i < taskType.NumField(); i++ {
+		field := taskType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		fields = append(fields, frontmatterField{key: key, index: i})
+	}
+	return fields
+}
+
+// Extra returns frontmatter keys that Task has no field for, such as
+// aliases or cssclass added by hand or by other tools.
+func (t *Task) Extra() map[string]any {
+	if t.frontmatter == nil {
+		return nil
+	}
+	known := map[string]bool{}
+	for _, field := range frontmatterFields() {
+		known[field.key] = true
+	}
+
+	extra := map[string]any{}
+	content := t.frontmatter.Content
+	for i := 0; i+1 < len(content); i += 2 {
+		key := content[i].Value
+		if known[key] {
+			continue
+		}
+		var value any
+		if err := content[i+1].Decode(&value); err == nil {
+			extra[key] = value
+		}
+	}
+	return extra
+}
+
+// build the frontmatter to write, editing the original node in place so
+// only fields that actually changed are re-rendered
+func (t *Task) frontmatterNode() (*yaml.Node, error) {
+	var fresh yaml.Node
+	if err := fresh.Encode(t); err != nil {
+		return nil, err
+	}
+	if t.frontmatter == nil {
+		return &fresh, nil
+	}
+
+	var original Task
+	if err := t.frontmatter.Decode(&original); err != nil {
+		// the original no longer decodes cleanly; keep its unknown keys
+		// but let every known field come from the task
+		original = Task{}
+	}
+
+	freshValues := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(fresh.Content); i += 2 {
+		freshValues[fresh.Content[i].Value] = fresh.Content[i+1]
+	}
+
+	fields := frontmatterFields()
+	fieldByKey := make(map[string]frontmatterField, len(fields))
+	for _, field := range fields {
+		fieldByKey[field.key] = field
+	}
+
+	current := reflect.ValueOf(t).Elem()
+	previous := reflect.ValueOf(original)
+
+	merged := *t.frontmatter
+	merged.Content = make([]*yaml.Node, 0, len(t.frontmatter.Content))
+	present := map[string]bool{}
+	src := t.frontmatter.Content
+	for i := 0; i+1 < len(src); i += 2 {
+		keyNode, valueNode := src[i], src[i+1]
+		field, known := fieldByKey[keyNode.Value]
+		if !known {
+			merged.Content = append(merged.Content, keyNode, valueNode)
+			continue
+		}
+		present[field.key] = true
+		if sameFieldValue(previous.Field(field.index), current.Field(field.index)) {
+			merged.Content = append(merged.Content, keyNode, valueNode)
+			continue
+		}
+		replacement, ok := freshValues[field.key]
+		if !ok {
+			// the field was cleared, so drop the key
+			continue
+		}
+		carryValueStyle(valueNode, replacement)
+		merged.Content = append(merged.Content, keyNode, replacement)
+	}
+
+	// add newly set fields after the nearest earlier field already present
+	for i, field := range fields {
+		value, ok := freshValues[field.key]
+		if !ok || present[field.key] {
+			continue
+		}
+		insertAt := 0
+		for j := i - 1; j >= 0; j-- {
+			if idx := mappingKeyIndex(&merged, fields[j].key); idx >= 0 {
+				insertAt = idx + 2
+				break
+			}
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: field.key}
+		merged.Content = append(merged.Content[:insertAt],
+			append([]*yaml.Node{keyNode, value}, merged.Content[insertAt:]...)...)
+		present[field.key] = true
+	}
+
+	return &merged, nil
+}
+
+// find the content index of a key in a mapping node
+func mappingKeyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// keep comments and flow style from the value being replaced
+func carryValueStyle(old, replacement *yaml.Node) {
+	replacement.HeadComment = old.HeadComment
+	replacement.LineComment = old.LineComment
+	replacement.FootComment = old.FootComment
+	if old.Kind == replacement.Kind && old.Kind != yaml.ScalarNode {
+		replacement.Style = old.Style
+	}
+}
+
+// compare field values, treating equal instants and empty lists as the same
+func sameFieldValue(a, b reflect.Value) bool {
+	switch av := a.Interface().(type) {
+	case time.Time:
+		return av.Equal(b.Interface().(time.Time))
+	case *time.Time:
+		bv := b.Interface().(*time.Time)
+		if av == nil || bv == nil {
+			return av == nil && bv == nil
+		}
+		return av.Equal(*bv)
+	}
+	if a.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	if reflect.DeepEqual(a.Interface(), b.Interface()) {
+		return true
+	}
+	// nested values such as transition lists hold times whose locations
+	// differ after decoding, so compare what would be written
+	aYAML, aErr := yaml.Marshal(a.Interface())
+	bYAML, bErr := yaml.Marshal(b.Interface())
+	return aErr == nil && bErr == nil && bytes.Equal(aYAML, bYAML)
+}
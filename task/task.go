@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"punchlist/fsutil"
 	"strings"
 	"time"
 
@@ -23,40 +24,61 @@ const (
 	StateConfirm State = "CONFIRM"
 )
 
-// parse a state token into a canonical state
+// parse a state token into a canonical state using the active workflow
 func ParseState(input string) (State, bool) {
-	switch strings.ToUpper(strings.TrimSpace(input)) {
-	case string(StateTodo):
-		return StateTodo, true
-	case string(StateBegun):
-		return StateBegun, true
-	case string(StateNotDo):
-		return StateNotDo, true
-	case string(StateDone):
-		return StateDone, true
-	case string(StateBlock):
-		return StateBlock, true
-	case string(StateConfirm):
-		return StateConfirm, true
-	default:
-		return "", false
-	}
+	return activeWorkflow.Lookup(input)
 }
 
 // task is the canonical in-memory representation
 type Task struct {
-	ID           int        `yaml:"id"`
-	Title        string     `yaml:"title"`
-	State        State      `yaml:"state"`
-	Priority     int        `yaml:"priority,omitempty"`
-	Due          *time.Time `yaml:"due,omitempty"`
-	Tags         []string   `yaml:"tags,omitempty"`
-	CreatedAt    time.Time  `yaml:"created_at"`
-	UpdatedAt    time.Time  `yaml:"updated_at"`
-	StartedAt    *time.Time `yaml:"started_at,omitempty"`
-	CompletedAt  *time.Time `yaml:"completed_at,omitempty"`
-	ExternalRefs []string   `yaml:"external_refs,omitempty"`
-	Body         string     `yaml:"-"`
+	ID           int          `yaml:"id"`
+	Title        string       `yaml:"title"`
+	State        State        `yaml:"state"`
+	Priority     int          `yaml:"priority,omitempty"`
+	Due          *time.Time   `yaml:"due,omitempty"`
+	Tags         []string     `yaml:"tags,omitempty"`
+	CreatedAt    time.Time    `yaml:"created_at"`
+	UpdatedAt    time.Time    `yaml:"updated_at"`
+	StartedAt    *time.Time   `yaml:"started_at,omitempty"`
+	CompletedAt  *time.Time   `yaml:"completed_at,omitempty"`
+	ExternalRefs []string     `yaml:"external_refs,omitempty"`
+	Transitions  []Transition `yaml:"transitions,omitempty"`
+	Body         string       `yaml:"-"`
+
+	// frontmatter as read from disk, kept so unknown keys, key order, and
+	// comments survive a rewrite
+	frontmatter *yaml.Node
+	// file the task was last read from or written to
+	path string
+}
+
+// Path returns the file the task was last read from or written to.
+func (t *Task) Path() string {
+	return t.path
+}
+
+// Transition records one change of state.
+type Transition struct {
+	At     time.Time `yaml:"at"`
+	From   State     `yaml:"from"`
+	To     State     `yaml:"to"`
+	Reason string    `yaml:"reason,omitempty"`
+}
+
+// RecordTransition moves the task to a new state and appends the change to
+// its history. Moving to the current state records nothing.
+func (t *Task) RecordTransition(to State, reason string, at time.Time) bool {
+	if t.State == to {
+		return false
+	}
+	t.Transitions = append(t.Transitions, Transition{
+		At:     at,
+		From:   t.State,
+		To:     to,
+		Reason: reason,
+	})
+	t.State = to
+	return true
 }
 
 // frontmatterSeparator defines yaml delimiters
@@ -102,25 +124,41 @@ func Parse(filePath string) (*Task, error) {
 		return nil, fmt.Errorf("failed to read task file: %w", err)
 	}
 
-	var task Task
-	if err := yaml.Unmarshal([]byte(yamlContent.String()), &task); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(yamlContent.String()), &doc); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal frontmatter: %w", err)
 	}
 
+	var task Task
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		mapping := doc.Content[0]
+		if err := mapping.Decode(&task); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal frontmatter: %w", err)
+		}
+		if mapping.Kind == yaml.MappingNode {
+			task.frontmatter = mapping
+		}
+	}
+
 	task.Body = strings.TrimSpace(bodyContent.String())
+	task.path = filePath
 
 	return &task, nil
 }
 
-// write serializes a Task back to disk
+// write serializes a Task back to disk, replacing the file atomically
 func (t *Task) Write(filePath string) error {
 	var buf bytes.Buffer
 
 	// write frontmatter
 	buf.WriteString(frontmatterSeparator + "\n")
+	node, err := t.frontmatterNode()
+	if err != nil {
+		return fmt.Errorf("failed to encode frontmatter: %w", err)
+	}
 	encoder := yaml.NewEncoder(&buf)
 	encoder.SetIndent(2)
-	if err := encoder.Encode(t); err != nil {
+	if err := encoder.Encode(node); err != nil {
 		return fmt.Errorf("failed to encode frontmatter: %w", err)
 	}
 	buf.WriteString(frontmatterSeparator + "\n")
@@ -134,5 +172,9 @@ func (t *Task) Write(filePath string) error {
 		}
 	}
 
-	return os.WriteFile(filePath, buf.Bytes(), 0644)
+	if err := fsutil.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	t.path = filePath
+	return nil
 }
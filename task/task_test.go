@@ -89,4 +89,59 @@ func TestParseAndWriteTask(t *testing.T) {
 			t.Errorf("Expected Title '%s', got '%s'", task.Title, parsedTask.Title)
 		}
 	})
+	t.Run("preserves unknown keys and comments on rewrite", func(t *testing.T) {
+		content := `---
+# managed by obsidian
+aliases: [launch-plan]
+id: 3
+title: Hand Edited
+state: TODO # change me
+owner: sam
+created_at: 2025-01-01T09:00:00Z
+updated_at: 2025-01-01T09:00:00Z
+---
+
+# Hand Edited
+`
+		filePath := filepath.Join(sandboxDir, "hand_edited.md")
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write task file: %v", err)
+		}
+
+		parsedTask, err := Parse(filePath)
+		if err != nil {
+			t.Fatalf("Parse() failed: %v", err)
+		}
+		if parsedTask.Extra()["owner"] != "sam" {
+			t.Errorf("Expected extra owner key, got %v", parsedTask.Extra())
+		}
+
+		parsedTask.State = StateDone
+		parsedTask.Priority = 2
+		if err := parsedTask.Write(filePath); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+
+		written, err := os.ReadFile(filePath)
+		if err != nil {
+			t.Fatalf("Failed to read task file: %v", err)
+		}
+		expected := `---
+# managed by obsidian
+aliases: [launch-plan]
+id: 3
+title: Hand Edited
+state: DONE # change me
+priority: 2
+owner: sam
+created_at: 2025-01-01T09:00:00Z
+updated_at: 2025-01-01T09:00:00Z
+---
+
+# Hand Edited
+`
+		if string(written) != expected {
+			t.Errorf("Unexpected rewrite.\nExpected:\n%s\nGot:\n%s", expected, written)
+		}
+	})
 }
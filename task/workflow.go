@@ -0,0 +1,126 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StateDef describes one state a task can be in.
+type StateDef struct {
+	Name    State
+	Aliases []string
+	// Closed states are finished, such as DONE and NOTDO.
+	Closed bool
+}
+
+// Workflow is the set of states and allowed moves for a project.
+type Workflow struct {
+	States []StateDef
+	// Transitions maps a state to the states it may move to. States
+	// without an entry, or a nil map, may move anywhere.
+	Transitions map[State][]State
+}
+
+// workflow used by ParseState and friends
+var activeWorkflow = DefaultWorkflow()
+
+// DefaultWorkflow returns the built-in states with no transition rules.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		States: []StateDef{
+			{Name: StateTodo},
+			{Name: StateBegun},
+			{Name: StateNotDo, Closed: true},
+			{Name: StateDone, Closed: true},
+			{Name: StateBlock},
+			{Name: StateConfirm},
+		},
+	}
+}
+
+// SetWorkflow replaces the active workflow; nil restores the default.
+func SetWorkflow(w *Workflow) {
+	if w == nil {
+		w = DefaultWorkflow()
+	}
+	activeWorkflow = w
+}
+
+// ActiveWorkflow returns the workflow in effect.
+func ActiveWorkflow() *Workflow {
+	return activeWorkflow
+}
+
+// Lookup maps a state name or alias to its canonical state.
+func (w *Workflow) Lookup(input string) (State, bool) {
+	label := strings.ToUpper(strings.TrimSpace(input))
+	if label == "" {
+		return "", false
+	}
+	for _, def := range w.States {
+		if string(def.Name) == label {
+			return def.Name, true
+		}
+	}
+	for _, def := range w.States {
+		for _, alias := range def.Aliases {
+			if strings.ToUpper(alias) == label {
+				return def.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Names lists canonical states in declaration order.
+func (w *Workflow) Names() []State {
+	names := make([]State, 0, len(w.States))
+	for _, def := range w.States {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// Def returns the definition of a canonical state.
+func (w *Workflow) Def(state State) (StateDef, bool) {
+	for _, def := range w.States {
+		if def.Name == state {
+			return def, true
+		}
+	}
+	return StateDef{}, false
+}
+
+// IsClosed reports whether a state counts as finished.
+func (w *Workflow) IsClosed(state State) bool {
+	def, ok := w.Def(state)
+	return ok && def.Closed
+}
+
+// CheckTransition explains why a task may not move from one state to
+// another, or returns nil when the move is allowed.
+func (w *Workflow) CheckTransition(from, to State) error {
+	if _, ok := w.Def(to); !ok {
+		return fmt.Errorf("unknown state %s", to)
+	}
+	if from == to || w.Transitions == nil {
+		return nil
+	}
+	allowed, restricted := w.Transitions[from]
+	if !restricted {
+		return nil
+	}
+	for _, state := range allowed {
+		if state == to {
+			return nil
+		}
+	}
+	if len(allowed) == 0 {
+		return fmt.Errorf("cannot move from %s to %s: %s has no allowed transitions", from, to, from)
+	}
+	names := make([]string, 0, len(allowed))
+	for _, state := range allowed {
+		names = append(names, string(state))
+	}
+	return fmt.Errorf("cannot move from %s to %s: %s may only move to %s", from, to, from, strings.Join(names, ", "))
+}